@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"myproject/config"
+	"myproject/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminAuth: /admin needs "Authorization: Bearer <ADMIN_TOKEN>"
+func adminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin token required", "code": "unauthorized"})
+		}
+	}
+}
+
+// registerWebhooks: Targets listed in the config file
+func registerWebhooks(hooks *webhook.Dispatcher, list []config.Webhook) {
+	for _, w := range list {
+		_, err := hooks.Add(webhook.Target{
+			URL:    w.URL,
+			Secret: w.Secret,
+			Filter: webhook.Filter{Provider: w.Provider, Number: w.Number, Country: w.Country, Service: w.Service},
+		})
+		if err != nil {
+			log.Printf("[Config] %v", err)
+		}
+	}
+}
+
+// mountAdmin: Without ADMIN_TOKEN the admin API stays off (403), it can
+// point webhooks at any URL
+func mountAdmin(r *gin.Engine, hooks *webhook.Dispatcher) {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		log.Println("[Admin] ADMIN_TOKEN not set, /admin disabled")
+		r.Any("/admin/*path", func(c *gin.Context) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin API disabled, set ADMIN_TOKEN", "code": "forbidden"})
+		})
+		return
+	}
+	admin := r.Group("/admin", adminAuth(token))
+
+	// ================= WEBHOOKS =================
+	admin.GET("/webhooks", func(c *gin.Context) {
+		c.JSON(http.StatusOK, hooks.Targets())
+	})
+
+	// Body: {"url": "...", "secret": "...", "filter": {"provider": "...", "number": "...", "country": "...", "service": "..."}}
+	// The secret is only ever returned here, keep it to verify X-Webhook-Signature.
+	admin.POST("/webhooks", func(c *gin.Context) {
+		var t webhook.Target
+		if err := c.ShouldBindJSON(&t); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "bad_request"})
+			return
+		}
+		created, err := hooks.Add(t)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "bad_request"})
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	})
+
+	admin.DELETE("/webhooks/:id", func(c *gin.Context) {
+		if !hooks.Remove(c.Param("id")) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown webhook", "code": "not_found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	admin.GET("/dead-letters", func(c *gin.Context) {
+		c.JSON(http.StatusOK, hooks.DeadLetters())
+	})
+
+	admin.DELETE("/dead-letters", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"cleared": hooks.ClearDeadLetters()})
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"myproject/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+func adminRequest(r *gin.Engine, token string) int {
+	req := httptest.NewRequest("POST", "/admin/webhooks", strings.NewReader(`{"url": "http://127.0.0.1:1/hook"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAdminNeedsToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Setenv("ADMIN_TOKEN", "")
+	r := gin.New()
+	mountAdmin(r, webhook.New())
+	if code := adminRequest(r, ""); code != http.StatusForbidden {
+		t.Errorf("without ADMIN_TOKEN: %d, want 403", code)
+	}
+
+	t.Setenv("ADMIN_TOKEN", "s3cret")
+	r = gin.New()
+	mountAdmin(r, webhook.New())
+	if code := adminRequest(r, ""); code != http.StatusUnauthorized {
+		t.Errorf("no Authorization header: %d, want 401", code)
+	}
+	if code := adminRequest(r, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d, want 401", code)
+	}
+	if code := adminRequest(r, "s3cret"); code != http.StatusCreated {
+		t.Errorf("right token: %d, want 201", code)
+	}
+}
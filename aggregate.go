@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/poller"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------------------- ALL PROVIDERS ----------------------
+
+// Default time each provider gets to answer on the aggregated routes
+const fanOutTimeout = 15 * time.Second
+
+// fanOutResult: One provider's answer
+type fanOutResult[T any] struct {
+	name  string
+	value T
+	err   error
+}
+
+// mountAggregate: Routes that read every provider at once
+func mountAggregate(r *gin.Engine, sched *poller.Poller) {
+	r.GET("/sms", func(c *gin.Context) { serveAllSMS(c, sched) })
+	r.GET("/numbers", serveAllNumbers)
+}
+
+// serveAllSMS: GET /sms
+//
+// Typed records of every provider (or ?provider=a,b), newest first. Without
+// query params the poller snapshots are merged; with the ?from= &limit= ...
+// params of /v2/<name>/sms every panel is queried live. ?timeout= bounds
+// each provider (default 15s); late or failing ones end up in "errors"
+// and the rest is still served.
+func serveAllSMS(c *gin.Context, sched *poller.Poller) {
+	providers, ok := selectProviders(c)
+	if !ok {
+		return
+	}
+	q, live, ok := smsQuery(c)
+	if !ok {
+		return
+	}
+	timeout, ok := fanOutTimeoutParam(c)
+	if !ok {
+		return
+	}
+
+	// Every provider is asked for the first offset+limit rows so the merged
+	// list can be paged as a whole
+	sub := q
+	sub.Offset = 0
+	if q.Limit > 0 {
+		sub.Limit = q.Offset + q.Limit
+	}
+
+	results := fanOut(providers, timeout, func(p provider.Provider) (provider.SMSFeed, error) {
+		if live {
+			return querySMS(p, sub)
+		}
+		feed, _, err := sched.SMS(p)
+		return feed, err
+	})
+
+	records := []provider.SMSRecord{}
+	for _, res := range results {
+		if res.err == nil {
+			records = append(records, res.value.Records...)
+		}
+	}
+	errs := fanOutErrors(results)
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if q.Ascending() {
+			return records[i].Time.Before(records[j].Time)
+		}
+		return records[i].Time.After(records[j].Time)
+	})
+	if c.Query("only_otp") == "true" {
+		records = onlyOTP(records)
+	}
+	total := len(records)
+	records = provider.Window(records, q.Offset, q.Limit)
+
+	respondFanOut(c, len(providers), errs, gin.H{"count": len(records), "total": total, "records": records})
+}
+
+// fanOutTimeoutParam: ?timeout= per provider, default fanOutTimeout
+func fanOutTimeoutParam(c *gin.Context) (time.Duration, bool) {
+	v := c.Query("timeout")
+	if v == "" {
+		return fanOutTimeout, true
+	}
+	d, err := parseTimeout(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "bad_request"})
+		return 0, false
+	}
+	return d, true
+}
+
+// fanOutErrors: {"<provider>": {"error", "code"}} for the failed ones
+func fanOutErrors[T any](results []fanOutResult[T]) gin.H {
+	errs := gin.H{}
+	for _, res := range results {
+		if res.err != nil {
+			errs[res.name] = gin.H{"error": res.err.Error(), "code": provider.Classify(res.err).Code}
+		}
+	}
+	return errs
+}
+
+// respondFanOut: 200 with "errors" when anything came back, 502 when every
+// provider failed
+func respondFanOut(c *gin.Context, providers int, errs gin.H, body gin.H) {
+	body["errors"] = errs
+	status := http.StatusOK
+	if providers > 0 && len(errs) == providers {
+		status = http.StatusBadGateway
+		body["error"], body["code"] = "every provider failed", "all_failed"
+	}
+	c.JSON(status, body)
+}
+
+// serveAllNumbers: GET /numbers
+//
+// Inventory of every provider (or ?provider=a,b) in one list, filtered by
+//
+//	?country=DZ,EG  ?calling_code=213  ?range=mobilis  ?period=weekly
+//	?min_price= &max_price=
+//
+// ?group_by=country returns counts per country (and per provider) instead
+// of the rows. Failures and ?timeout= work as on GET /sms.
+func serveAllNumbers(c *gin.Context) {
+	providers, ok := selectProviders(c)
+	if !ok {
+		return
+	}
+	f, ok := parseNumberFilter(c)
+	if !ok {
+		return
+	}
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "country" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be country", "code": "bad_request"})
+		return
+	}
+	timeout, ok := fanOutTimeoutParam(c)
+	if !ok {
+		return
+	}
+
+	results := fanOut(providers, timeout, func(p provider.Provider) ([]provider.NumberRecord, error) {
+		return p.FetchNumbers()
+	})
+
+	records := []provider.NumberRecord{}
+	for _, res := range results {
+		for _, rec := range res.value {
+			if f.match(rec) {
+				records = append(records, rec)
+			}
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Number != records[j].Number {
+			return records[i].Number < records[j].Number
+		}
+		return records[i].Provider < records[j].Provider
+	})
+	errs := fanOutErrors(results)
+
+	if groupBy == "country" {
+		groups := groupByCountry(records)
+		respondFanOut(c, len(providers), errs, gin.H{"count": len(records), "countries": len(groups), "groups": groups})
+		return
+	}
+	respondFanOut(c, len(providers), errs, gin.H{"count": len(records), "records": records})
+}
+
+// numberFilter: Search params of GET /numbers, zero values match everything
+type numberFilter struct {
+	countries   map[string]bool
+	callingCode int
+	rangeText   string
+	period      provider.Period
+	minPrice    *float64
+	maxPrice    *float64
+}
+
+func parseNumberFilter(c *gin.Context) (numberFilter, bool) {
+	f := numberFilter{rangeText: strings.ToLower(c.Query("range"))}
+	bad := func(msg string) (numberFilter, bool) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg, "code": "bad_request"})
+		return f, false
+	}
+
+	if v := c.Query("country"); v != "" {
+		f.countries = make(map[string]bool)
+		for _, cc := range strings.Split(v, ",") {
+			f.countries[strings.ToUpper(strings.TrimSpace(cc))] = true
+		}
+	}
+	if v := c.Query("calling_code"); v != "" {
+		code, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(v), "+")) // "+" arrives as a space unless encoded
+		if err != nil || code <= 0 {
+			return bad("invalid calling_code: " + v)
+		}
+		f.callingCode = code
+	}
+	if v := c.Query("period"); v != "" {
+		f.period = provider.ParsePeriod(v)
+		if f.period == provider.PeriodUnknown && !strings.EqualFold(v, string(provider.PeriodUnknown)) {
+			return bad("period must be daily, weekly, monthly or unknown")
+		}
+	}
+	for name, dst := range map[string]**float64{"min_price": &f.minPrice, "max_price": &f.maxPrice} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return bad("invalid " + name + ": " + v)
+		}
+		*dst = &price
+	}
+	return f, true
+}
+
+func (f numberFilter) match(rec provider.NumberRecord) bool {
+	switch {
+	case f.countries != nil && !f.countries[rec.Country]:
+		return false
+	case f.callingCode != 0 && rec.CallingCode != f.callingCode:
+		return false
+	case f.rangeText != "" && !strings.Contains(strings.ToLower(rec.Range), f.rangeText):
+		return false
+	case f.period != "" && rec.Period != f.period:
+		return false
+	case f.minPrice != nil && rec.Price < *f.minPrice:
+		return false
+	case f.maxPrice != nil && rec.Price > *f.maxPrice:
+		return false
+	}
+	return true
+}
+
+// countryGroup: ?group_by=country row, "how many DZ numbers and where"
+type countryGroup struct {
+	Country     string         `json:"country"`
+	CallingCode int            `json:"calling_code,omitempty"`
+	Count       int            `json:"count"`
+	Providers   map[string]int `json:"providers"`
+}
+
+// groupByCountry: Biggest country first
+func groupByCountry(records []provider.NumberRecord) []countryGroup {
+	index := make(map[string]int)
+	groups := []countryGroup{}
+	for _, rec := range records {
+		i, ok := index[rec.Country]
+		if !ok {
+			i = len(groups)
+			index[rec.Country] = i
+			groups = append(groups, countryGroup{Country: rec.Country, CallingCode: rec.CallingCode, Providers: make(map[string]int)})
+		}
+		groups[i].Count++
+		groups[i].Providers[rec.Provider]++
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Country < groups[j].Country
+	})
+	return groups
+}
+
+// selectProviders: ?provider=a,b, default all
+func selectProviders(c *gin.Context) ([]provider.Provider, bool) {
+	v := c.Query("provider")
+	if v == "" {
+		return provider.All(), true
+	}
+	var out []provider.Provider
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		p, ok := provider.Get(name)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider: " + name, "code": "not_found"})
+			return nil, false
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, p)
+		}
+	}
+	return out, true
+}
+
+// fanOut: Runs fetch for every provider concurrently. Providers still busy
+// after timeout are reported as timed out; their calls finish in the
+// background and are dropped.
+func fanOut[T any](providers []provider.Provider, timeout time.Duration, fetch func(provider.Provider) (T, error)) []fanOutResult[T] {
+	done := make(chan fanOutResult[T], len(providers))
+	for _, p := range providers {
+		go func(p provider.Provider) {
+			value, err := fetch(p)
+			done <- fanOutResult[T]{name: p.Name(), value: value, err: err}
+		}(p)
+	}
+
+	got := make(map[string]fanOutResult[T])
+	deadline := time.After(timeout)
+wait:
+	for len(got) < len(providers) {
+		select {
+		case res := <-done:
+			got[res.name] = res
+		case <-deadline:
+			break wait
+		}
+	}
+
+	out := make([]fanOutResult[T], 0, len(providers))
+	for _, p := range providers {
+		res, ok := got[p.Name()]
+		if !ok {
+			res = fanOutResult[T]{name: p.Name(), err: fmt.Errorf("%s: %w: no answer within %s", p.Name(), provider.ErrUpstreamTimeout, timeout)}
+		}
+		out = append(out, res)
+	}
+	return out
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"myproject/poller"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fanOutStub: Fixed numbers and SMS, or err; delay holds every answer back
+type fanOutStub struct {
+	stubProvider
+	numbers []provider.NumberRecord
+	sms     []provider.SMSRecord
+	err     error
+	delay   time.Duration
+}
+
+func (s *fanOutStub) FetchNumbers() ([]provider.NumberRecord, error) {
+	time.Sleep(s.delay)
+	return s.numbers, s.err
+}
+
+func (s *fanOutStub) PollSMS() (provider.SMSFeed, error) {
+	time.Sleep(s.delay)
+	return provider.SMSFeed{Records: s.sms}, s.err
+}
+
+func number(prov, num, country string, code int, rng string, period provider.Period, price float64) provider.NumberRecord {
+	return provider.NumberRecord{Provider: prov, Number: num, Country: country, CallingCode: code, Range: rng, Period: period, Price: price}
+}
+
+var (
+	aggA = &fanOutStub{stubProvider: stubProvider{name: "agg-a"}, numbers: []provider.NumberRecord{
+		number("agg-a", "+213551000001", "DZ", 213, "Algeria Mobilis", provider.PeriodWeekly, 0.5),
+		number("agg-a", "+213551000002", "DZ", 213, "Algeria Mobilis", provider.PeriodWeekly, 0.5),
+		number("agg-a", "+201000000001", "EG", 20, "Egypt Vodafone", provider.PeriodMonthly, 2),
+	}, sms: []provider.SMSRecord{
+		{ID: "a1", Provider: "agg-a", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+	}}
+	aggB = &fanOutStub{stubProvider: stubProvider{name: "agg-b"}, numbers: []provider.NumberRecord{
+		number("agg-b", "+213661000001", "DZ", 213, "Algeria Djezzy", provider.PeriodDaily, 0.1),
+		number("agg-b", "+212600000001", "MA", 212, "Morocco IAM", provider.PeriodWeekly, 1.2),
+	}, sms: []provider.SMSRecord{
+		{ID: "b1", Provider: "agg-b", Time: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)},
+	}}
+	aggDown = &fanOutStub{stubProvider: stubProvider{name: "agg-down"}, err: &provider.ErrBlocked{Provider: "agg-down", RetryAfter: time.Minute}}
+	aggSlow = &fanOutStub{stubProvider: stubProvider{name: "agg-slow"}, delay: 500 * time.Millisecond, numbers: aggA.numbers}
+)
+
+func init() {
+	for _, p := range []provider.Provider{aggA, aggB, aggDown, aggSlow} {
+		provider.Register(p)
+	}
+}
+
+// aggregate: GET path on the aggregated routes, decoded
+func aggregate(t *testing.T, path string) (int, map[string]json.RawMessage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	mountAggregate(r, poller.New(time.Minute))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: %v in %s", path, err, w.Body)
+	}
+	return w.Code, body
+}
+
+func numbersOf(t *testing.T, body map[string]json.RawMessage) string {
+	t.Helper()
+	var records []provider.NumberRecord
+	json.Unmarshal(body["records"], &records)
+	out := make([]string, len(records))
+	for i, rec := range records {
+		out[i] = rec.Number
+	}
+	sort.Strings(out)
+	return strings.Join(out, ",")
+}
+
+func TestNumbersFilters(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "+201000000001,+212600000001,+213551000001,+213551000002,+213661000001"},
+		{"country=dz", "+213551000001,+213551000002,+213661000001"},
+		{"country=EG,MA", "+201000000001,+212600000001"},
+		{"calling_code=213", "+213551000001,+213551000002,+213661000001"},
+		{"calling_code=%2B20", "+201000000001"},
+		{"calling_code=+212", "+212600000001"}, // "+" decoded as a space
+		{"range=MOBILIS", "+213551000001,+213551000002"},
+		{"period=weekly", "+212600000001,+213551000001,+213551000002"},
+		{"period=daily", "+213661000001"},
+		{"min_price=0.5&max_price=1.2", "+212600000001,+213551000001,+213551000002"},
+		{"min_price=1.5", "+201000000001"},
+		{"country=DZ&period=weekly&max_price=1", "+213551000001,+213551000002"},
+		{"country=FR", ""},
+	}
+	for _, tt := range tests {
+		path := "/numbers?provider=agg-a,agg-b&" + tt.query
+		code, body := aggregate(t, path)
+		if code != http.StatusOK {
+			t.Errorf("GET %s = %d", path, code)
+			continue
+		}
+		if got := numbersOf(t, body); got != tt.want {
+			t.Errorf("GET %s = %s, want %s", path, got, tt.want)
+		}
+	}
+}
+
+func TestNumbersBadParams(t *testing.T) {
+	for _, query := range []string{"calling_code=abc", "period=yearly", "min_price=cheap", "group_by=range", "timeout=soon"} {
+		if code, _ := aggregate(t, "/numbers?provider=agg-a&"+query); code != http.StatusBadRequest {
+			t.Errorf("?%s = %d, want 400", query, code)
+		}
+	}
+	if code, _ := aggregate(t, "/numbers?provider=agg-a,nope"); code != http.StatusNotFound {
+		t.Errorf("unknown provider = %d, want 404", code)
+	}
+}
+
+func TestNumbersGroupByCountry(t *testing.T) {
+	code, body := aggregate(t, "/numbers?provider=agg-a,agg-b&group_by=country")
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	var groups []countryGroup
+	json.Unmarshal(body["groups"], &groups)
+	want := []countryGroup{
+		{Country: "DZ", CallingCode: 213, Count: 3, Providers: map[string]int{"agg-a": 2, "agg-b": 1}},
+		{Country: "EG", CallingCode: 20, Count: 1, Providers: map[string]int{"agg-a": 1}},
+		{Country: "MA", CallingCode: 212, Count: 1, Providers: map[string]int{"agg-b": 1}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("groups = %+v, want %+v", groups, want)
+	}
+	for i := range want {
+		g, w := groups[i], want[i]
+		if g.Country != w.Country || g.CallingCode != w.CallingCode || g.Count != w.Count || len(g.Providers) != len(w.Providers) {
+			t.Errorf("group %d = %+v, want %+v", i, g, w)
+			continue
+		}
+		for prov, n := range w.Providers {
+			if g.Providers[prov] != n {
+				t.Errorf("group %s: %s has %d, want %d", g.Country, prov, g.Providers[prov], n)
+			}
+		}
+	}
+	if string(body["count"]) != "5" || string(body["countries"]) != "3" {
+		t.Errorf("count %s countries %s, want 5 and 3", body["count"], body["countries"])
+	}
+}
+
+// fanOutErrs: The "errors" object, code per provider
+func fanOutErrs(t *testing.T, body map[string]json.RawMessage) map[string]string {
+	t.Helper()
+	var errs map[string]struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(body["errors"], &errs)
+	out := map[string]string{}
+	for name, e := range errs {
+		out[name] = e.Code
+	}
+	return out
+}
+
+// One provider failing still serves the others, with its error listed
+func TestFanOutPartialFailure(t *testing.T) {
+	for path, count := range map[string]string{
+		"/numbers?provider=agg-b,agg-down": "2",
+		"/sms?provider=agg-b,agg-down":     "1",
+	} {
+		code, body := aggregate(t, path)
+		if code != http.StatusOK || string(body["count"]) != count {
+			t.Errorf("GET %s = %d count %s, want 200 with agg-b's %s", path, code, body["count"], count)
+		}
+		if errs := fanOutErrs(t, body); len(errs) != 1 || errs["agg-down"] != "blocked" {
+			t.Errorf("GET %s: errors = %v, want agg-down blocked", path, errs)
+		}
+		if _, ok := body["code"]; ok {
+			t.Errorf("GET %s: top-level code %s on a partial answer", path, body["code"])
+		}
+	}
+}
+
+func TestFanOutAllFailed(t *testing.T) {
+	for _, path := range []string{"/numbers?provider=agg-down", "/sms?provider=agg-down"} {
+		code, body := aggregate(t, path)
+		if code != http.StatusBadGateway || string(body["code"]) != `"all_failed"` {
+			t.Errorf("GET %s = %d code %s, want 502 all_failed", path, code, body["code"])
+		}
+		if errs := fanOutErrs(t, body); errs["agg-down"] != "blocked" {
+			t.Errorf("GET %s: errors = %v", path, errs)
+		}
+	}
+}
+
+// A provider slower than ?timeout= is reported as timed out, the answer
+// doesn't wait for it
+func TestFanOutTimeout(t *testing.T) {
+	start := time.Now()
+	code, body := aggregate(t, "/numbers?provider=agg-b,agg-slow&timeout=50ms")
+	if took := time.Since(start); took > 400*time.Millisecond {
+		t.Errorf("answered after %s, want about the 50ms timeout", took)
+	}
+	if code != http.StatusOK {
+		t.Errorf("status %d, want 200", code)
+	}
+	if errs := fanOutErrs(t, body); len(errs) != 1 || errs["agg-slow"] != "upstream_timeout" {
+		t.Errorf("errors = %v, want agg-slow upstream_timeout", errs)
+	}
+	if got := numbersOf(t, body); got != "+212600000001,+213661000001" {
+		t.Errorf("numbers = %s, want only agg-b's", got)
+	}
+
+	// Long enough: everything arrives
+	code, body = aggregate(t, "/numbers?provider=agg-b,agg-slow&timeout=5s")
+	if errs := fanOutErrs(t, body); code != http.StatusOK || len(errs) != 0 {
+		t.Errorf("timeout=5s: %d errors %v", code, errs)
+	}
+}
+
+// /sms merges every provider newest first
+func TestAllSMSMerged(t *testing.T) {
+	code, body := aggregate(t, "/sms?provider=agg-a,agg-b")
+	var records []provider.SMSRecord
+	json.Unmarshal(body["records"], &records)
+	if code != http.StatusOK || len(records) != 2 || records[0].ID != "b1" || records[1].ID != "a1" {
+		t.Errorf("GET /sms = %d %v, want b1 then a1", code, records)
+	}
+}
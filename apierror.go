@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strconv"
+
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError: Upstream failures as {"error", "code"} with a matching
+// status (503 + Retry-After when blocked, 502 bad panel, 504 timeout) so
+// clients can back off instead of retrying blindly.
+func respondError(c *gin.Context, err error) {
+	info := provider.Classify(err)
+	body := gin.H{"error": err.Error(), "code": info.Code}
+	if info.RetryAfter > 0 {
+		secs := int(info.RetryAfter.Seconds() + 0.999)
+		c.Header("Retry-After", strconv.Itoa(secs))
+		body["retry_after"] = secs
+	}
+	c.JSON(info.Status, body)
+}
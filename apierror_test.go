@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err    error
+		status int
+		code   string
+		retry  string
+	}{
+		{&provider.ErrBlocked{Provider: "mait", Reason: "403", RetryAfter: 1500 * time.Millisecond}, http.StatusServiceUnavailable, "blocked", "2"},
+		{fmt.Errorf("mait: %w", provider.ErrUpstreamTimeout), http.StatusGatewayTimeout, "upstream_timeout", ""},
+		{fmt.Errorf("mait: %w", provider.ErrCaptcha), http.StatusBadGateway, "captcha_failed", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		respondError(c, tt.err)
+
+		var body struct {
+			Error      string `json:"error"`
+			Code       string `json:"code"`
+			RetryAfter int    `json:"retry_after"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != tt.status || body.Code != tt.code || body.Error != tt.err.Error() {
+			t.Errorf("%v: %d %s, want %d %s", tt.err, w.Code, w.Body, tt.status, tt.code)
+		}
+		if got := w.Header().Get("Retry-After"); got != tt.retry {
+			t.Errorf("%v: Retry-After = %q, want %q", tt.err, got, tt.retry)
+		}
+		if tt.retry != "" && fmt.Sprint(body.RetryAfter) != tt.retry {
+			t.Errorf("%v: retry_after = %d, want %s", tt.err, body.RetryAfter, tt.retry)
+		}
+	}
+}
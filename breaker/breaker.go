@@ -0,0 +1,250 @@
+package breaker
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Circuit breaker per panel. Closed: requests go through and failures are
+// counted. Threshold failures in a row (or one IP block via Trip) open it:
+// requests fail fast until the cooldown ends. Then it is half-open: one
+// probe request goes through; success closes it, failure opens it again
+// with twice the cooldown (plus jitter, capped at MaxCooldown), so a
+// misbehaving panel is left alone instead of being hammered into a ban.
+
+type State string
+
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half_open"
+)
+
+type Config struct {
+	Threshold    int           // Consecutive failures that open the circuit
+	BaseCooldown time.Duration // First open period
+	MaxCooldown  time.Duration // Cap for the doubling
+	Jitter       float64       // +/- fraction of the cooldown, spreads retries
+}
+
+// Defaults: Used by Get
+var Defaults = Config{
+	Threshold:    5,
+	BaseCooldown: 30 * time.Second,
+	MaxCooldown:  15 * time.Minute,
+	Jitter:       0.2,
+}
+
+// OpenError: Returned while the circuit is open
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s: circuit open, retry in %ds", e.Name, int(e.RetryAfter.Seconds()+0.999))
+}
+
+// Status: Snapshot for the status endpoint
+type Status struct {
+	Name       string     `json:"name"`
+	State      State      `json:"state"`
+	Failures   int        `json:"failures"` // Consecutive, while closed
+	Trips      int        `json:"trips"`    // Opens in a row, drives the cooldown
+	OpenedAt   *time.Time `json:"opened_at,omitempty"`
+	RetryAfter int        `json:"retry_after"` // Seconds until half-open
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+type Breaker struct {
+	name string
+	cfg  Config
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	trips    int
+	openedAt time.Time
+	until    time.Time
+	probing  bool // Half-open probe in flight
+	lastErr  string
+
+	now func() time.Time // time.Now; tests move it by hand
+}
+
+func New(name string, cfg Config) *Breaker {
+	return &Breaker{name: name, cfg: cfg, state: Closed, now: time.Now}
+}
+
+// left: Time until the open period ends, mu held
+func (b *Breaker) left() time.Duration {
+	return b.until.Sub(b.now())
+}
+
+// Check: Like Allow, but doesn't claim the half-open probe. For callers
+// about to start a sequence of requests (login).
+func (b *Breaker) Check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if wait := b.left(); wait > 0 {
+			return &OpenError{Name: b.name, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// Allow: nil if a request may go out now. Every allowed request must be
+// followed by Success or Failure.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if wait := b.left(); wait > 0 {
+			return &OpenError{Name: b.name, RetryAfter: wait}
+		}
+		fmt.Printf("[Breaker] %s half-open, probing\n", b.name)
+		b.state = HalfOpen
+		b.probing = true
+	case HalfOpen:
+		if b.probing {
+			return &OpenError{Name: b.name, RetryAfter: time.Second}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success: Closes the circuit and resets the backoff. Late answers to
+// requests sent before it opened don't count.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		return
+	}
+	if b.state != Closed {
+		fmt.Printf("[Breaker] %s closed\n", b.name)
+	}
+	b.state = Closed
+	b.failures = 0
+	b.trips = 0
+	b.probing = false
+}
+
+// Failure: Counts towards Threshold; a failed probe reopens at once
+func (b *Breaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil {
+		b.lastErr = err.Error()
+	}
+	if b.state == Open {
+		return
+	}
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.cfg.Threshold {
+		b.open(0)
+	}
+}
+
+// Trip: Opens the circuit now (IP block seen), for at least min. Already
+// open, it is only extended, so one block seen twice (status, then body)
+// counts once. Returns how long it stays open.
+func (b *Breaker) Trip(reason string, min time.Duration) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastErr = reason
+	if b.state == Open && b.left() > 0 {
+		if b.left() < min {
+			b.until = b.now().Add(min)
+		}
+	} else {
+		b.open(min)
+	}
+	return b.left()
+}
+
+// open: mu held
+func (b *Breaker) open(min time.Duration) {
+	cooldown := b.cfg.BaseCooldown << b.trips
+	if cooldown > b.cfg.MaxCooldown || cooldown <= 0 {
+		cooldown = b.cfg.MaxCooldown
+	}
+	if b.cfg.Jitter > 0 {
+		cooldown += time.Duration(float64(cooldown) * b.cfg.Jitter * (2*rand.Float64() - 1))
+	}
+	if cooldown < min {
+		cooldown = min
+	}
+
+	b.state = Open
+	b.trips++
+	b.probing = false
+	b.openedAt = b.now()
+	b.until = b.openedAt.Add(cooldown)
+	fmt.Printf("[Breaker] %s open for %s (trip %d): %s\n", b.name, cooldown.Round(time.Second), b.trips, b.lastErr)
+}
+
+// IsOpen: Requests are currently refused
+func (b *Breaker) IsOpen() bool {
+	return b.Check() != nil
+}
+
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Status{Name: b.name, State: b.state, Failures: b.failures, Trips: b.trips, LastError: b.lastErr}
+	if b.state != Closed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+	if wait := b.left(); b.state == Open && wait > 0 {
+		s.RetryAfter = int(wait.Seconds() + 0.999)
+	}
+	return s
+}
+
+// =========================================================
+// REGISTRY (one breaker per panel, shared by its accounts)
+// =========================================================
+var (
+	registry = make(map[string]*Breaker)
+	regMutex sync.Mutex
+)
+
+// Get returns the breaker for name, creating it with Defaults.
+func Get(name string) *Breaker {
+	regMutex.Lock()
+	defer regMutex.Unlock()
+
+	b, ok := registry[name]
+	if !ok {
+		b = New(name, Defaults)
+		registry[name] = b
+	}
+	return b
+}
+
+// All returns every breaker's status, by name.
+func All() []Status {
+	regMutex.Lock()
+	out := make([]Status, 0, len(registry))
+	for _, b := range registry {
+		out = append(out, b.Status())
+	}
+	regMutex.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
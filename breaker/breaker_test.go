@@ -0,0 +1,216 @@
+package breaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testBreaker: No jitter and a clock that only moves through advance
+func testBreaker(cfg Config) (*Breaker, func(time.Duration)) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b := New("test", cfg)
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+var testConfig = Config{Threshold: 3, BaseCooldown: time.Second, MaxCooldown: 5 * time.Second}
+
+func openError(t *testing.T, err error) *OpenError {
+	t.Helper()
+	var open *OpenError
+	if !errors.As(err, &open) {
+		t.Fatalf("err = %v, want OpenError", err)
+	}
+	return open
+}
+
+func TestOpensAtThreshold(t *testing.T) {
+	b, _ := testBreaker(testConfig)
+	fail := errors.New("HTTP 502")
+	for i := 1; i < testConfig.Threshold; i++ {
+		b.Failure(fail)
+		if err := b.Allow(); err != nil {
+			t.Fatalf("after %d failures: %v, want closed", i, err)
+		}
+	}
+	b.Failure(fail)
+	if open := openError(t, b.Allow()); open.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %s, want the base cooldown", open.RetryAfter)
+	}
+	if s := b.Status(); s.State != Open || s.Trips != 1 || s.LastError != "HTTP 502" || s.RetryAfter != 1 {
+		t.Errorf("status = %+v", s)
+	}
+}
+
+// A success in between resets the count
+func TestSuccessResetsFailures(t *testing.T) {
+	b, _ := testBreaker(testConfig)
+	b.Failure(nil)
+	b.Failure(nil)
+	b.Success()
+	b.Failure(nil)
+	b.Failure(nil)
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow = %v, want closed", err)
+	}
+}
+
+func TestOpenShortCircuits(t *testing.T) {
+	b, advance := testBreaker(testConfig)
+	b.Trip("IP blocked", 10*time.Second)
+
+	advance(4 * time.Second)
+	if open := openError(t, b.Allow()); open.RetryAfter != 6*time.Second {
+		t.Errorf("Allow RetryAfter = %s, want 6s", open.RetryAfter)
+	}
+	if open := openError(t, b.Check()); open.RetryAfter != 6*time.Second {
+		t.Errorf("Check RetryAfter = %s, want 6s", open.RetryAfter)
+	}
+	if !b.IsOpen() {
+		t.Error("IsOpen = false")
+	}
+	// Late answers to requests sent earlier change nothing
+	b.Success()
+	b.Failure(errors.New("late"))
+	if s := b.Status(); s.State != Open || s.Trips != 1 {
+		t.Errorf("status after late answers = %+v", s)
+	}
+}
+
+func TestHalfOpenSingleProbe(t *testing.T) {
+	b, advance := testBreaker(testConfig)
+	b.Trip("IP blocked", 0)
+	advance(time.Second)
+
+	if err := b.Check(); err != nil {
+		t.Fatalf("Check after the cooldown = %v", err)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if b.Status().State != HalfOpen {
+		t.Fatalf("state = %s, want half_open", b.Status().State)
+	}
+	for i := 0; i < 3; i++ {
+		openError(t, b.Allow()) // Only one probe at a time
+	}
+
+	b.Success()
+	if s := b.Status(); s.State != Closed || s.Trips != 0 {
+		t.Errorf("after the probe succeeded: %+v", s)
+	}
+	if err := b.Allow(); err != nil {
+		t.Errorf("Allow after close = %v", err)
+	}
+}
+
+// Each failed probe doubles the cooldown up to MaxCooldown; closing resets it
+func TestCooldownDoubles(t *testing.T) {
+	b, advance := testBreaker(testConfig)
+	b.Trip("IP blocked", 0)
+
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		advance(b.left())
+		if err := b.Allow(); err != nil {
+			t.Fatalf("probe refused: %v", err)
+		}
+		b.Failure(errors.New("probe failed"))
+		if open := openError(t, b.Allow()); open.RetryAfter != want {
+			t.Errorf("cooldown = %s, want %s", open.RetryAfter, want)
+		}
+	}
+
+	advance(b.left())
+	b.Allow()
+	b.Success()
+	b.Trip("IP blocked", 0)
+	if open := openError(t, b.Allow()); open.RetryAfter != time.Second {
+		t.Errorf("cooldown after closing = %s, want the base again", open.RetryAfter)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	cfg := testConfig
+	cfg.BaseCooldown = 10 * time.Second
+	cfg.MaxCooldown = time.Minute
+	cfg.Jitter = 0.2
+	for i := 0; i < 50; i++ {
+		b, _ := testBreaker(cfg)
+		if wait := b.Trip("x", 0); wait < 8*time.Second || wait > 12*time.Second {
+			t.Fatalf("cooldown %s outside 10s +/- 20%%", wait)
+		}
+	}
+}
+
+// Trip on an open breaker only extends it: one block seen twice is one trip
+func TestTripExtends(t *testing.T) {
+	b, advance := testBreaker(testConfig)
+	if wait := b.Trip("403", 10*time.Second); wait != 10*time.Second {
+		t.Fatalf("Trip = %s, want at least min (10s)", wait)
+	}
+	advance(2 * time.Second)
+	if wait := b.Trip("403 body", 5*time.Second); wait != 8*time.Second {
+		t.Errorf("shorter Trip = %s, want the 8s left", wait)
+	}
+	if wait := b.Trip("403 body", 20*time.Second); wait != 20*time.Second {
+		t.Errorf("longer Trip = %s, want 20s", wait)
+	}
+	if s := b.Status(); s.Trips != 1 || s.LastError != "403 body" {
+		t.Errorf("status = %+v, want one trip", s)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var code, hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(code.Load()))
+	}))
+	defer srv.Close()
+
+	get := func(b *Breaker) error {
+		client := &http.Client{Transport: &Transport{Breaker: b}}
+		resp, err := client.Get(srv.URL + "/api")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	cfg := Config{Threshold: 2, BaseCooldown: time.Minute, MaxCooldown: time.Minute}
+	tests := []struct {
+		codes []int32
+		open  bool
+	}{
+		{[]int32{500, 429}, true},
+		{[]int32{502, 503}, true},
+		{[]int32{500, 200, 500}, false}, // The 200 resets the count
+		{[]int32{404, 404, 404}, false}, // Not the panel's fault
+		{[]int32{403}, true},            // IP block: trips at once
+	}
+	for _, tt := range tests {
+		b, _ := testBreaker(cfg)
+		for _, c := range tt.codes {
+			code.Store(c)
+			if err := get(b); err != nil {
+				t.Fatalf("%v: %v", tt.codes, err)
+			}
+		}
+		if b.IsOpen() != tt.open {
+			t.Errorf("%v: open = %v, want %v", tt.codes, b.IsOpen(), tt.open)
+		}
+		if !tt.open {
+			continue
+		}
+		// Open: fails fast without reaching the panel
+		before := hits.Load()
+		openError(t, get(b))
+		if hits.Load() != before {
+			t.Errorf("%v: request reached the server while open", tt.codes)
+		}
+	}
+}
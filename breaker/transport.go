@@ -0,0 +1,37 @@
+package breaker
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Transport: http.RoundTripper that sends every request through a breaker.
+// Transport errors, 429 and 5xx count as failures; 403 trips it at once
+// (that's how the panels ban an IP).
+type Transport struct {
+	Breaker *Breaker
+	Base    http.RoundTripper // nil: http.DefaultTransport
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Breaker.Allow(); err != nil {
+		return nil, err
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.Breaker.Failure(err)
+	case resp.StatusCode == http.StatusForbidden:
+		t.Breaker.Trip(fmt.Sprintf("HTTP %d on %s", resp.StatusCode, req.URL.Path), 0)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		t.Breaker.Failure(fmt.Errorf("HTTP %d on %s", resp.StatusCode, req.URL.Path))
+	default:
+		t.Breaker.Success()
+	}
+	return resp, err
+}
@@ -0,0 +1,63 @@
+package captcha
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Login captchas. A Solver gets the whole login page HTML and returns the
+// value to post as "capt". Panels pick one by name (config "captcha") or set
+// ints.Config.Captcha directly; the default is the arithmetic solver.
+//
+// testdata/ holds saved login pages, each with its expected answer in a
+// leading <!-- want: N --> comment ("error" when there is no challenge).
+
+type Solver interface {
+	Solve(page string) (string, error)
+}
+
+// SolverFunc adapts a plain function to Solver.
+type SolverFunc func(page string) (string, error)
+
+func (f SolverFunc) Solve(page string) (string, error) { return f(page) }
+
+// ErrNoCaptcha: The page has nothing the solver recognises
+var ErrNoCaptcha = errors.New("captcha: no challenge found on login page")
+
+const DefaultSolver = "math"
+
+var (
+	solvers = map[string]Solver{DefaultSolver: Math{}}
+	mu      sync.RWMutex
+)
+
+// Register makes a solver available by name.
+func Register(name string, s Solver) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := solvers[name]; exists {
+		panic(fmt.Sprintf("captcha: solver %q registered twice", name))
+	}
+	solvers[name] = s
+}
+
+func Get(name string) (Solver, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := solvers[name]
+	return s, ok
+}
+
+// Names: Registered solvers, sorted
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]string, 0, len(solvers))
+	for name := range solvers {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
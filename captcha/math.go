@@ -0,0 +1,195 @@
+package captcha
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Math solves "What is 7 + 3 = ?" style challenges:
+//   - operators + - * / and x × ÷, or plus/minus/times/multiplied by/divided by
+//   - spelled-out numbers ("seven plus two", "twenty-one minus 4")
+//   - digits hidden in markup: entities (&#55;), split tags (<b>1</b>2) and
+//     decoy elements with display:none / visibility:hidden are ignored
+type Math struct{}
+
+var (
+	// Preferred: the question itself
+	reQuestion = regexp.MustCompile(`what\s+is\s+(-?\d+)\s*([-+*/])\s*(-?\d+)`)
+	// Fallback: "12 - 5 = ?" / "12 - 5 =" anywhere on the page
+	reEquation = regexp.MustCompile(`(-?\d+)\s*([-+*/])\s*(-?\d+)\s*=`)
+
+	reWordHyphen = regexp.MustCompile(`([a-z])-([a-z])`)
+	reTimesX     = regexp.MustCompile(`(\d)\s*x\s*(\d)`)
+	reWordOps    = strings.NewReplacer(
+		"multiplied by", " * ", "divided by", " / ",
+		"×", " * ", "✕", " * ", "÷", " / ", "−", " - ", "–", " - ",
+	)
+)
+
+var wordOps = map[string]string{"plus": "+", "minus": "-", "less": "-", "times": "*", "into": "*", "over": "/"}
+
+func (Math) Solve(page string) (string, error) {
+	text := normalize(visibleText(page))
+
+	m := reQuestion.FindStringSubmatch(text)
+	if m == nil {
+		m = reEquation.FindStringSubmatch(text)
+	}
+	if m == nil {
+		return "", ErrNoCaptcha
+	}
+
+	a, _ := strconv.Atoi(m[1])
+	b, _ := strconv.Atoi(m[3])
+	switch m[2] {
+	case "+":
+		return strconv.Itoa(a + b), nil
+	case "-":
+		return strconv.Itoa(a - b), nil
+	case "*":
+		return strconv.Itoa(a * b), nil
+	default:
+		if b == 0 {
+			return "", errors.New("captcha: division by zero in " + strconv.Quote(m[0]))
+		}
+		if a%b == 0 {
+			return strconv.Itoa(a / b), nil
+		}
+		return strconv.FormatFloat(float64(a)/float64(b), 'f', -1, 64), nil
+	}
+}
+
+// visibleText: Page text as a browser would show it (entities decoded,
+// scripts/styles/hidden elements dropped, inline tags glued together)
+func visibleText(page string) string {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return page
+	}
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && hidden(n) {
+			return
+		}
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && block[n.Data]:
+			sb.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if n.Type == html.ElementNode && block[n.Data] {
+			sb.WriteString("\n")
+		}
+	}
+	walk(doc)
+	return sb.String()
+}
+
+// block: Elements that break a line, so text of neighbours doesn't merge
+var block = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+	"label": true, "h1": true, "h2": true, "h3": true, "h4": true, "form": true, "input": true,
+}
+
+func hidden(n *html.Node) bool {
+	switch n.Data {
+	case "script", "style", "noscript", "template", "head":
+		return true
+	}
+	for _, a := range n.Attr {
+		switch strings.ToLower(a.Key) {
+		case "hidden":
+			return true
+		case "style":
+			style := strings.ToLower(strings.ReplaceAll(a.Val, " ", ""))
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		case "type":
+			if strings.EqualFold(a.Val, "hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalize: Lowercase, spelled-out numbers and operators to symbols
+func normalize(text string) string {
+	text = strings.ToLower(text)
+	text = reWordOps.Replace(text)
+	text = reWordHyphen.ReplaceAllString(text, "$1 $2")
+	text = reTimesX.ReplaceAllString(text, "$1 * $2")
+
+	var out []string
+	fields := strings.Fields(text)
+	for i := 0; i < len(fields); {
+		if n, used := parseNumber(fields[i:]); used > 0 {
+			out = append(out, strconv.Itoa(n))
+			i += used
+			continue
+		}
+		word := strings.Trim(fields[i], "?:,.!")
+		if op, ok := wordOps[word]; ok {
+			out = append(out, op)
+		} else {
+			out = append(out, fields[i])
+		}
+		i++
+	}
+	return strings.Join(out, " ")
+}
+
+var (
+	units = map[string]int{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+		"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+		"thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16,
+		"seventeen": 17, "eighteen": 18, "nineteen": 19,
+	}
+	tens = map[string]int{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+		"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	}
+)
+
+// parseNumber: Reads "one hundred and twenty three" from the start of
+// words. Returns how many words it used (0 = not a spelled number).
+func parseNumber(words []string) (int, int) {
+	total, current, used := 0, 0, 0
+	for i, w := range words {
+		w = strings.Trim(w, "?:,.!")
+		switch {
+		case units[w] > 0 || w == "zero":
+			current += units[w]
+		case tens[w] > 0:
+			current += tens[w]
+		case w == "hundred" && used > 0:
+			current *= 100
+		case w == "thousand" && used > 0:
+			total += current * 1000
+			current = 0
+		case w == "and" && used > 0 && i+1 < len(words) && isNumberWord(words[i+1]):
+			// "one hundred and five"
+		default:
+			return total + current, used
+		}
+		used = i + 1
+	}
+	return total + current, used
+}
+
+func isNumberWord(w string) bool {
+	w = strings.Trim(w, "?:,.!")
+	_, u := units[w]
+	_, t := tens[w]
+	return u || t
+}
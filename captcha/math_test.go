@@ -0,0 +1,88 @@
+package captcha
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var reWant = regexp.MustCompile(`^<!-- want: (.+?) -->`)
+
+// TestMathFixtures: Every saved login page gives its <!-- want: --> answer
+func TestMathFixtures(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("no fixtures in testdata/")
+	}
+	for _, path := range pages {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := reWant.FindSubmatch(raw)
+			if m == nil {
+				t.Fatal("fixture has no <!-- want: --> header")
+			}
+			want := string(m[1])
+
+			got, err := Math{}.Solve(string(raw))
+			if want == "error" {
+				if !errors.Is(err, ErrNoCaptcha) {
+					t.Errorf("got %q, %v; want ErrNoCaptcha", got, err)
+				}
+				return
+			}
+			if err != nil || got != want {
+				t.Errorf("got %q, %v; want %q", got, err, want)
+			}
+		})
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name, page string
+		noCaptcha  bool
+	}{
+		{"empty", "", true},
+		{"no question", "<form><input name=capt></form>", true},
+		{"hidden question", `<div style="display:none">What is 2 + 3 = ?</div>`, true},
+		{"missing operand", "What is 7 + ? =", true},
+		{"unknown word", "What is seven plus many?", true},
+		{"unclosed markup", "<div><b>What is <i>", true},
+		{"division by zero", "What is 7 / 0 = ?", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Math{}.Solve(tc.page)
+			if err == nil {
+				t.Fatalf("got %q, want an error", got)
+			}
+			if errors.Is(err, ErrNoCaptcha) != tc.noCaptcha {
+				t.Errorf("err = %v, ErrNoCaptcha = %v", err, tc.noCaptcha)
+			}
+		})
+	}
+}
+
+func TestMathOperators(t *testing.T) {
+	for page, want := range map[string]string{
+		"What is 7 + 3 = ?":                     "10",
+		"What is 12 - 20 = ?":                   "-8",
+		"What is 6 x 7 = ?":                     "42",
+		"What is 6 × 7 = ?":                     "42",
+		"What is 9 ÷ 2 = ?":                     "4.5",
+		"What is twenty-one minus 4?":           "17",
+		"What is one hundred and five plus 5 ?": "110",
+		"Solve: 8 multiplied by 3 =":            "24",
+	} {
+		if got, err := (Math{}).Solve(page); err != nil || got != want {
+			t.Errorf("%q: got %q, %v; want %q", page, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"myproject/ints"
+	"myproject/mockpanel"
+	"myproject/provider"
+
+	_ "myproject/dgroup"
+	_ "myproject/mait"
+	_ "myproject/npmneon"
+	_ "myproject/numberpanel"
+)
+
+// Standalone fake panel for running the API offline. -panel copies the
+// path, role, auth and SMS columns of a built-in panel:
+//
+//	go run ./cmd/mockpanel -addr :9000 -panel mait
+//	PANEL_MAIT_URL=http://localhost:9000 PANEL_MAIT_USERNAME=user PANEL_MAIT_PASSWORD=pass go run .
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	name := flag.String("panel", "", "built-in panel to imitate (d-group, mait, npm-neon, number-panel)")
+	opts := mockpanel.Options{}
+	flag.StringVar(&opts.Path, "path", "/ints", "panel path (/ints or /NumberPanel)")
+	flag.StringVar(&opts.Role, "role", "client", "client or agent")
+	flag.StringVar(&opts.Auth, "auth", "sesskey", "sesskey, csstr or cookie")
+	flag.StringVar(&opts.ReportsPage, "reports", "", "reports page (default by role)")
+	flag.StringVar(&opts.Username, "user", "user", "username")
+	flag.StringVar(&opts.Password, "pass", "pass", "password")
+	flag.DurationVar(&opts.SessionTTL, "session-ttl", 0, "expire sessions after this long (0 = never)")
+	newEvery := flag.Duration("new-sms", time.Minute, "add a fresh SMS this often (0 = off)")
+	flag.Parse()
+
+	if *name != "" {
+		def, ok := ints.Definition(*name)
+		if !ok {
+			log.Fatalf("[MockPanel] unknown panel %q, have %v", *name, ints.Names())
+		}
+		opts.Panel, opts.Path, opts.Role, opts.ReportsPage = def.Name, def.Path, def.Role, def.ReportsPage
+		opts.Auth = def.Auth.String()
+	}
+
+	panel := mockpanel.New(opts)
+	if *newEvery > 0 {
+		go func() {
+			for range time.Tick(*newEvery) {
+				panel.AddSMS(mockpanel.PanelSMS(opts.Panel, opts.Role, time.Now().In(provider.PanelLocation))[0])
+			}
+		}()
+	}
+
+	log.Printf("[MockPanel] %s role=%s auth=%s on %s", opts.Path, opts.Role, opts.Auth, *addr)
+	log.Fatal(http.ListenAndServe(*addr, panel))
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPath: Used when CONFIG_FILE is not set
+const DefaultPath = "config.json"
+
+// Panel: One panel as listed in the config file.
+// Empty fields keep the built-in definition's value (see ints.Define).
+type Panel struct {
+	Name     string    `json:"name"`               // URL name, e.g. "d-group"
+	Template string    `json:"template,omitempty"` // Built-in definition to start from (default: Name)
+	BaseURL  string    `json:"base_url,omitempty"`
+	Path     string    `json:"path,omitempty"`     // "/ints" or "/NumberPanel"
+	Role     string    `json:"role,omitempty"`     // "client" or "agent"
+	Username string    `json:"username,omitempty"` // Single account shorthand
+	Password string    `json:"password,omitempty"`
+	Accounts []Account `json:"accounts,omitempty"` // More logins on the same panel
+	Enabled  *bool     `json:"enabled,omitempty"`  // Default true
+	Captcha  string    `json:"captcha,omitempty"`  // Solver name (default: built-in "math")
+
+	PollInterval Duration `json:"poll_interval,omitempty"` // Overrides the global interval
+}
+
+type Account struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Alias    string `json:"alias,omitempty"` // Extra URL name serving this account alone (e.g. the old "number-panel-1")
+}
+
+type Config struct {
+	PollInterval Duration  `json:"poll_interval,omitempty"` // SMS poll interval, e.g. "10s"
+	Panels       []Panel   `json:"panels"`
+	Webhooks     []Webhook `json:"webhooks,omitempty"`
+	HistoryDB    string    `json:"history_db,omitempty"` // SMS history file, "off" disables
+	RecordDir    string    `json:"record_dir,omitempty"` // Save raw panel responses here (fixtures)
+	WSOrigins    []string  `json:"ws_origins,omitempty"` // Other-origin web pages allowed on /ws
+
+	// Zone of the panels' clocks, e.g. "Africa/Algiers" (default UTC)
+	PanelTimezone string         `json:"panel_timezone,omitempty"`
+	PanelLocation *time.Location `json:"-"`
+
+	// Encrypted panel sessions; the key only comes from SESSION_KEY and
+	// must be long and random (it is hashed once, not stretched)
+	SessionFile string `json:"session_file,omitempty"`
+	SessionKey  string `json:"-"`
+}
+
+// Webhook: Target registered at startup (more can be added via /admin/webhooks)
+type Webhook struct {
+	URL      string `json:"url"`
+	Secret   string `json:"secret,omitempty"` // HMAC key, random if empty
+	Provider string `json:"provider,omitempty"`
+	Number   string `json:"number,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Service  string `json:"service,omitempty"`
+}
+
+// Duration: "10s" / "1m" in JSON, bare numbers are seconds
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	parsed, err := parseDuration(v)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func parseDuration(v interface{}) (time.Duration, error) {
+	switch val := v.(type) {
+	case float64:
+		return time.Duration(val * float64(time.Second)), nil
+	case string:
+		if secs, err := strconv.ParseFloat(val, 64); err == nil {
+			return time.Duration(secs * float64(time.Second)), nil
+		}
+		return time.ParseDuration(val)
+	}
+	return 0, fmt.Errorf("invalid duration: %v", v)
+}
+
+func (p Panel) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// Logins: username/password (if set) followed by Accounts
+func (p Panel) Logins() []Account {
+	var out []Account
+	if p.Username != "" || p.Password != "" {
+		out = append(out, Account{Username: p.Username, Password: p.Password})
+	}
+	return append(out, p.Accounts...)
+}
+
+// ErrNoPanels: Neither the config file nor the environment lists a panel
+var ErrNoPanels = errors.New("config: no panels configured (add them to the config file or set PANEL_<NAME>_USERNAME/_PASSWORD)")
+
+// Load reads the JSON config (path from CONFIG_FILE, else DefaultPath) and
+// then applies POLL_INTERVAL, HISTORY_DB, RECORD_DIR, WS_ORIGINS,
+// PANEL_TIMEZONE, SESSION_FILE/SESSION_KEY and PANEL_<NAME>_* environment
+// overrides. known are the built-in panel names: one missing from the file
+// is added when PANEL_<NAME>_USERNAME or _ACCOUNTS is set, so a deployment
+// can run from the environment alone.
+func Load(known ...string) (*Config, error) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		path = DefaultPath
+	}
+
+	cfg := &Config{}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("config: %s: %w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Println("[Config] " + path + " not found, using environment only")
+	default:
+		return nil, err
+	}
+
+	if v, ok := os.LookupEnv("POLL_INTERVAL"); ok {
+		d, err := parseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("config: POLL_INTERVAL: %w", err)
+		}
+		cfg.PollInterval = Duration(d)
+	}
+
+	if v, ok := os.LookupEnv("HISTORY_DB"); ok {
+		cfg.HistoryDB = v
+	}
+	if v, ok := os.LookupEnv("RECORD_DIR"); ok {
+		cfg.RecordDir = v
+	}
+	if v, ok := os.LookupEnv("WS_ORIGINS"); ok {
+		cfg.WSOrigins = strings.Split(v, ",")
+	}
+	if v, ok := os.LookupEnv("PANEL_TIMEZONE"); ok {
+		cfg.PanelTimezone = v
+	}
+	cfg.PanelLocation = time.UTC
+	if cfg.PanelTimezone != "" {
+		loc, err := time.LoadLocation(cfg.PanelTimezone)
+		if err != nil {
+			return nil, fmt.Errorf("config: panel_timezone: %w", err)
+		}
+		cfg.PanelLocation = loc
+	}
+	if v, ok := os.LookupEnv("SESSION_FILE"); ok {
+		cfg.SessionFile = v
+	}
+	cfg.SessionKey = os.Getenv("SESSION_KEY")
+
+	listed := map[string]bool{}
+	for i := range cfg.Panels {
+		if cfg.Panels[i].Name == "" {
+			return nil, fmt.Errorf("config: panel #%d has no name", i+1)
+		}
+		listed[cfg.Panels[i].Name] = true
+		applyEnv(&cfg.Panels[i])
+	}
+	for _, name := range known {
+		if listed[name] || !hasEnvLogin(name) {
+			continue
+		}
+		p := Panel{Name: name}
+		applyEnv(&p)
+		cfg.Panels = append(cfg.Panels, p)
+	}
+	if len(cfg.Panels) == 0 {
+		return nil, ErrNoPanels
+	}
+	return cfg, nil
+}
+
+// hasEnvLogin: PANEL_<NAME>_USERNAME or _ACCOUNTS is set
+func hasEnvLogin(name string) bool {
+	prefix := EnvPrefix(name)
+	return os.Getenv(prefix+"USERNAME") != "" || os.Getenv(prefix+"ACCOUNTS") != ""
+}
+
+// EnvPrefix: "d-group" -> "PANEL_D_GROUP_"
+func EnvPrefix(name string) string {
+	key := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name))
+	return "PANEL_" + key + "_"
+}
+
+// applyEnv: PANEL_<NAME>_URL / _PATH / _ROLE / _USERNAME / _PASSWORD / _ENABLED / _POLL_INTERVAL,
+// and _ACCOUNTS="user1:pass1,user2:pass2" which replaces the accounts list
+func applyEnv(p *Panel) {
+	prefix := EnvPrefix(p.Name)
+	set := func(field *string, key string) {
+		if v, ok := os.LookupEnv(prefix + key); ok {
+			*field = v
+		}
+	}
+	set(&p.BaseURL, "URL")
+	set(&p.Path, "PATH")
+	set(&p.Role, "ROLE")
+	set(&p.Username, "USERNAME")
+	set(&p.Password, "PASSWORD")
+
+	if v, ok := os.LookupEnv(prefix + "ACCOUNTS"); ok {
+		p.Accounts = nil
+		for _, pair := range strings.Split(v, ",") {
+			user, pass, _ := strings.Cut(strings.TrimSpace(pair), ":")
+			if user != "" {
+				p.Accounts = append(p.Accounts, Account{Username: user, Password: pass})
+			}
+		}
+	}
+
+	if v, ok := os.LookupEnv(prefix + "POLL_INTERVAL"); ok {
+		if d, err := parseDuration(v); err == nil {
+			p.PollInterval = Duration(d)
+		} else {
+			fmt.Println("[Config] " + prefix + "POLL_INTERVAL: " + err.Error())
+		}
+	}
+
+	if v, ok := os.LookupEnv(prefix + "ENABLED"); ok {
+		enabled := v == "1" || strings.EqualFold(v, "true") || strings.EqualFold(v, "yes")
+		p.Enabled = &enabled
+	}
+}
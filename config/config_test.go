@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadEnvOnly(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("PANEL_MAIT_URL", "http://127.0.0.1:9000")
+	t.Setenv("PANEL_MAIT_USERNAME", "user")
+	t.Setenv("PANEL_MAIT_PASSWORD", "pass")
+
+	cfg, err := Load("d-group", "mait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Panels) != 1 {
+		t.Fatalf("got %d panels, want only mait", len(cfg.Panels))
+	}
+	p := cfg.Panels[0]
+	if p.Name != "mait" || p.BaseURL != "http://127.0.0.1:9000" || p.Username != "user" || p.Password != "pass" {
+		t.Errorf("panel = %+v", p)
+	}
+}
+
+func TestLoadNoPanels(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+
+	if _, err := Load("d-group", "mait"); !errors.Is(err, ErrNoPanels) {
+		t.Fatalf("err = %v, want ErrNoPanels", err)
+	}
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	file := `{"panels": [{"name": "d-group", "base_url": "http://file", "username": "a", "password": "b"}]}`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("PANEL_D_GROUP_URL", "http://env")
+	t.Setenv("PANEL_MAIT_ACCOUNTS", "u1:p1,u2:p2")
+
+	cfg, err := Load("d-group", "mait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Panels) != 2 {
+		t.Fatalf("got %d panels, want d-group and mait", len(cfg.Panels))
+	}
+	if cfg.Panels[0].BaseURL != "http://env" {
+		t.Errorf("d-group base_url = %q, want the env override", cfg.Panels[0].BaseURL)
+	}
+	if got := cfg.Panels[1].Logins(); len(got) != 2 || got[1].Username != "u2" {
+		t.Errorf("mait logins = %+v", got)
+	}
+}
+
+func TestLoadPanelTimezone(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv("PANEL_MAIT_USERNAME", "user")
+
+	cfg, err := Load("mait")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PanelLocation != time.UTC {
+		t.Errorf("default location = %v, want UTC", cfg.PanelLocation)
+	}
+
+	t.Setenv("PANEL_TIMEZONE", "Africa/Algiers")
+	if cfg, err = Load("mait"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PanelLocation.String() != "Africa/Algiers" {
+		t.Errorf("location = %v, want Africa/Algiers", cfg.PanelLocation)
+	}
+
+	t.Setenv("PANEL_TIMEZONE", "Mars/Olympus")
+	if _, err := Load("mait"); err == nil {
+		t.Error("unknown zone accepted")
+	}
+}
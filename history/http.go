@@ -0,0 +1,69 @@
+package history
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeSMS: GET /history/sms
+//
+//	?from= &to=          RFC3339, "2006-01-02 15:04:05", "2006-01-02" or unix seconds
+//	?provider=a,b        ?account=  ?number=  ?service=
+//	?q=                  full-text, all words must match
+//	?offset= &limit=     pagination (limit max 1000)
+//	?sort=asc            oldest first (?order=asc still works)
+func (s *Store) ServeSMS(c *gin.Context) {
+	q := Query{
+		Account:   c.Query("account"),
+		Number:    c.Query("number"),
+		Service:   c.Query("service"),
+		Text:      c.Query("q"),
+		Ascending: c.Query("sort") == "asc" || c.Query("order") == "asc",
+	}
+	if p := c.Query("provider"); p != "" {
+		q.Providers = strings.Split(p, ",")
+	}
+
+	var err error
+	if q.From, err = provider.ParseTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error(), "code": "bad_request"})
+		return
+	}
+	if q.To, err = provider.ParseTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error(), "code": "bad_request"})
+		return
+	}
+	if q.Offset, err = intParam(c, "offset"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "bad_request"})
+		return
+	}
+	if q.Limit, err = intParam(c, "limit"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "bad_request"})
+		return
+	}
+
+	page, err := s.Search(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "code": "internal"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func intParam(c *gin.Context, name string) (int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid " + name + ": " + v)
+	}
+	return n, nil
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeSMSSort(t *testing.T) {
+	loc, err := time.LoadLocation("Africa/Algiers")
+	if err != nil {
+		t.Skip(err)
+	}
+	saved := provider.PanelLocation
+	provider.PanelLocation = loc
+	t.Cleanup(func() { provider.PanelLocation = saved })
+
+	s, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var records []provider.SMSRecord
+	for i, stamp := range []string{"2024-05-01 09:00:00", "2024-05-01 10:00:00", "2024-05-01 11:00:00"} {
+		at, _ := time.ParseInLocation("2006-01-02 15:04:05", stamp, loc)
+		records = append(records, provider.SMSRecord{Provider: "mait", Number: "21355000000" + string(rune('0'+i)), Message: stamp, Time: at})
+	}
+	if err := s.Save("mait", records); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/history/sms", s.ServeSMS)
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"2024-05-01 11:00:00", "2024-05-01 10:00:00", "2024-05-01 09:00:00"}},
+		{"sort=asc", []string{"2024-05-01 09:00:00", "2024-05-01 10:00:00", "2024-05-01 11:00:00"}},
+		{"order=asc", []string{"2024-05-01 09:00:00", "2024-05-01 10:00:00", "2024-05-01 11:00:00"}},
+		// Zone-less bounds are panel time, not server time
+		{"sort=asc&from=2024-05-01+10:00:00", []string{"2024-05-01 10:00:00", "2024-05-01 11:00:00"}},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/sms?"+tc.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status %d: %s", tc.query, w.Code, w.Body)
+		}
+		var page Page
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, rec := range page.Records {
+			got = append(got, rec.Message)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%q: got %v, want %v", tc.query, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%q: got %v, want %v", tc.query, got, tc.want)
+				break
+			}
+		}
+	}
+}
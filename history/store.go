@@ -0,0 +1,349 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"myproject/poller"
+	"myproject/provider"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Persistent SMS history. Panels only show today's (or the last 100) rows,
+// so every record the poller sees is written to a local bbolt file.
+//
+// Records live in "sms" under 8 bytes of big-endian unix seconds followed by
+// the record ID (provider.Fingerprint), so a cursor walks the bucket in time
+// order. Dedup goes through "ids" (record ID -> that key) instead: the ID
+// hashes the panel's wall clock, so a changed PANEL_TIMEZONE, which moves
+// the unix seconds, doesn't store and emit every SMS a second time.
+//
+// The store doubles as the poller's Ledger. Records are saved only after
+// the poller has handed them on, so a crash in between re-emits them on
+// the next start: at-least-once, never lost (webhook receivers dedupe on
+// record.id). Webhook deliveries still in flight wait in "webhooks" (the
+// dispatcher's Journal) until a 2xx or the dead-letter list clears them.
+
+const (
+	DefaultPath = "history.db"
+
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+var (
+	bucketSMS       = []byte("sms")
+	bucketIDs       = []byte("ids")       // record ID -> key in bucketSMS
+	bucketProviders = []byte("providers") // name -> first ingest time
+	bucketPending   = []byte("webhooks")  // webhook.Journal: queued deliveries
+)
+
+type Store struct {
+	db *bolt.DB
+}
+
+// Open creates or opens the store at path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("history: %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		sms, err := tx.CreateBucketIfNotExists(bucketSMS)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(bucketProviders); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(bucketPending); err != nil {
+			return err
+		}
+		if tx.Bucket(bucketIDs) != nil {
+			return nil
+		}
+		// Files from before the ID index: build it from the record keys
+		ids, err := tx.CreateBucket(bucketIDs)
+		if err != nil {
+			return err
+		}
+		return sms.ForEach(func(k, _ []byte) error {
+			return ids.Put(k[8:], k)
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Unseen implements poller.Ledger: the records not stored yet, or none the
+// first time a provider shows up (its backlog is not news).
+func (s *Store) Unseen(name string, records []provider.SMSRecord) ([]provider.SMSRecord, error) {
+	var unseen []provider.SMSRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketProviders).Get([]byte(name)) == nil {
+			return nil
+		}
+		ids := tx.Bucket(bucketIDs)
+		for _, rec := range records {
+			if ids.Get([]byte(recordID(rec))) == nil {
+				unseen = append(unseen, rec)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	return unseen, nil
+}
+
+// Save implements poller.Ledger: stores records not stored yet and marks
+// the provider as known.
+func (s *Store) Save(name string, records []provider.SMSRecord) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		sms, ids := tx.Bucket(bucketSMS), tx.Bucket(bucketIDs)
+		for _, rec := range records {
+			rec.ID = recordID(rec)
+			if ids.Get([]byte(rec.ID)) != nil {
+				continue
+			}
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			k := recordKey(rec)
+			if err := sms.Put(k, data); err != nil {
+				return err
+			}
+			if err := ids.Put([]byte(rec.ID), k); err != nil {
+				return err
+			}
+		}
+
+		known := tx.Bucket(bucketProviders)
+		if known.Get([]byte(name)) != nil {
+			return nil
+		}
+		stamp, _ := time.Now().MarshalText()
+		return known.Put([]byte(name), stamp)
+	})
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	return nil
+}
+
+func recordID(rec provider.SMSRecord) string {
+	if rec.ID != "" {
+		return rec.ID
+	}
+	return provider.Fingerprint(rec)
+}
+
+// Count: Total number of stored records
+func (s *Store) Count() int {
+	n := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucketSMS).Stats().KeyN
+		return nil
+	})
+	return n
+}
+
+// ---------------------- WEBHOOK JOURNAL ----------------------
+
+// PutPending implements webhook.Journal: key stays until DeletePending.
+func (s *Store) PutPending(key string, data []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPending).Put([]byte(key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) DeletePending(key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPending).Delete([]byte(key))
+	})
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	return nil
+}
+
+// Pending: Every delivery not yet acknowledged or dead-lettered
+func (s *Store) Pending() (map[string][]byte, error) {
+	out := map[string][]byte{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPending).ForEach(func(k, v []byte) error {
+			out[string(k)] = append([]byte{}, v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("history: %w", err)
+	}
+	return out, nil
+}
+
+// recordKey: Time order for Search; dedup uses the ID alone (bucketIDs)
+func recordKey(rec provider.SMSRecord) []byte {
+	k := make([]byte, 8, 8+len(rec.ID))
+	binary.BigEndian.PutUint64(k, timeKey(rec.Time))
+	return append(k, rec.ID...)
+}
+
+// timeKey: Unix seconds, records without a time sort first
+func timeKey(t time.Time) uint64 {
+	if t.IsZero() || t.Unix() < 0 {
+		return 0
+	}
+	return uint64(t.Unix())
+}
+
+// ---------------------- QUERY ----------------------
+
+// Query: Empty fields match anything
+type Query struct {
+	From, To  time.Time // Inclusive, zero = open
+	Providers []string
+	Account   string
+	Number    string // Same number, with or without country code
+	Service   string // Case-insensitive, sender / OTP service / message
+	Text      string // Full-text: every word must appear somewhere in the record
+	Offset    int
+	Limit     int
+	Ascending bool // Default newest first
+}
+
+// Page: One page of results
+type Page struct {
+	Records    []provider.SMSRecord `json:"records"`
+	Count      int                  `json:"count"`
+	Offset     int                  `json:"offset"`
+	Limit      int                  `json:"limit"`
+	HasMore    bool                 `json:"has_more"`
+	NextOffset int                  `json:"next_offset,omitempty"`
+}
+
+func (q Query) match(rec provider.SMSRecord) bool {
+	if len(q.Providers) > 0 {
+		found := false
+		for _, p := range q.Providers {
+			if strings.EqualFold(strings.TrimSpace(p), rec.Provider) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Account != "" && rec.Account != q.Account {
+		return false
+	}
+	if !(poller.Filter{Number: q.Number, Service: q.Service}).Match(rec) {
+		return false
+	}
+	if q.Text != "" {
+		hay := strings.ToLower(strings.Join([]string{rec.Message, rec.Number, rec.Service, rec.Range, rec.Country, rec.Provider, rec.Account}, " "))
+		if rec.OTP != nil {
+			hay += " " + strings.ToLower(rec.OTP.Code+" "+rec.OTP.Service)
+		}
+		for _, word := range strings.Fields(strings.ToLower(q.Text)) {
+			if !strings.Contains(hay, word) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Search walks the time range in order and returns one page of matches.
+func (s *Store) Search(q Query) (Page, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	lo, hi := make([]byte, 8), make([]byte, 8)
+	binary.BigEndian.PutUint64(lo, 0)
+	binary.BigEndian.PutUint64(hi, math.MaxUint64)
+	if !q.From.IsZero() {
+		binary.BigEndian.PutUint64(lo, timeKey(q.From))
+	}
+	if !q.To.IsZero() {
+		binary.BigEndian.PutUint64(hi, timeKey(q.To))
+	}
+
+	page := Page{Records: []provider.SMSRecord{}, Offset: q.Offset, Limit: q.Limit}
+	skipped := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketSMS).Cursor()
+
+		var k, v []byte
+		var next func() ([]byte, []byte)
+		inRange := func(k []byte) bool {
+			return k != nil && bytes.Compare(k[:8], lo) >= 0 && bytes.Compare(k[:8], hi) <= 0
+		}
+		if q.Ascending {
+			k, v = c.Seek(lo)
+			next = c.Next
+		} else {
+			// Last key whose time part is <= hi
+			upper := append(append([]byte{}, hi...), 0xff)
+			if k, v = c.Seek(upper); k == nil {
+				k, v = c.Last()
+			} else if bytes.Compare(k, upper) > 0 {
+				k, v = c.Prev()
+			}
+			next = c.Prev
+		}
+
+		for ; inRange(k); k, v = next() {
+			var rec provider.SMSRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				continue
+			}
+			if !q.match(rec) {
+				continue
+			}
+			if skipped < q.Offset {
+				skipped++
+				continue
+			}
+			if len(page.Records) == q.Limit {
+				page.HasMore = true
+				page.NextOffset = q.Offset + q.Limit
+				return nil
+			}
+			page.Records = append(page.Records, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return Page{}, fmt.Errorf("history: %w", err)
+	}
+	page.Count = len(page.Records)
+	return page, nil
+}
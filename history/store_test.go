@@ -0,0 +1,129 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myproject/provider"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTemp(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func sms(at time.Time, msg string) provider.SMSRecord {
+	rec := provider.SMSRecord{Provider: "mait", Number: "213551234567", Message: msg, Time: at}
+	rec.ID = provider.Fingerprint(rec)
+	return rec
+}
+
+func TestLedger(t *testing.T) {
+	s, _ := openTemp(t)
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	first := []provider.SMSRecord{sms(at, "one")}
+
+	// Unknown provider: its backlog is the baseline
+	if got, err := s.Unseen("mait", first); err != nil || len(got) != 0 {
+		t.Fatalf("baseline Unseen = %v, %v; want none", got, err)
+	}
+	if err := s.Save("mait", first); err != nil {
+		t.Fatal(err)
+	}
+
+	next := append(first, sms(at.Add(time.Minute), "two"))
+	got, err := s.Unseen("mait", next)
+	if err != nil || len(got) != 1 || got[0].Message != "two" {
+		t.Fatalf("Unseen = %v, %v; want only the new SMS", got, err)
+	}
+	// Not saved yet: asking again returns it again (no loss before Save)
+	if got, _ := s.Unseen("mait", next); len(got) != 1 {
+		t.Fatalf("second Unseen = %v, want the new SMS again", got)
+	}
+	if err := s.Save("mait", next); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Unseen("mait", next); len(got) != 0 {
+		t.Errorf("after Save: Unseen = %v, want none", got)
+	}
+	if n := s.Count(); n != 2 {
+		t.Errorf("Count = %d, want 2", n)
+	}
+}
+
+// Re-reading the panel in another zone moves the unix time but not the ID:
+// nothing is stored or emitted twice
+func TestLedgerZoneChange(t *testing.T) {
+	algiers, err := time.LoadLocation("Africa/Algiers")
+	if err != nil {
+		t.Skip(err)
+	}
+	s, _ := openTemp(t)
+	utc := sms(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "code 1234")
+	if err := s.Save("mait", []provider.SMSRecord{utc}); err != nil {
+		t.Fatal(err)
+	}
+
+	local := sms(time.Date(2024, 5, 1, 10, 0, 0, 0, algiers), "code 1234")
+	if local.ID != utc.ID {
+		t.Fatalf("ID changed with the zone: %s vs %s", local.ID, utc.ID)
+	}
+	if got, _ := s.Unseen("mait", []provider.SMSRecord{local}); len(got) != 0 {
+		t.Errorf("Unseen after a zone change = %v, want none", got)
+	}
+	s.Save("mait", []provider.SMSRecord{local})
+	if n := s.Count(); n != 1 {
+		t.Errorf("Count = %d, want 1", n)
+	}
+}
+
+// A file written before the ID index gets one on open
+func TestOpenBuildsIDIndex(t *testing.T) {
+	s, path := openTemp(t)
+	rec := sms(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), "old")
+	if err := s.Save("mait", []provider.SMSRecord{rec}); err != nil {
+		t.Fatal(err)
+	}
+	s.db.Update(func(tx *bolt.Tx) error { return tx.DeleteBucket(bucketIDs) })
+	s.Close()
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got, _ := s.Unseen("mait", []provider.SMSRecord{rec}); len(got) != 0 {
+		t.Errorf("Unseen = %v, want the old record known", got)
+	}
+}
+
+// Pending webhook deliveries survive a reopen until deleted
+func TestPendingJournal(t *testing.T) {
+	s, path := openTemp(t)
+	s.PutPending("sms-1 https://a.example/", []byte(`{"attempt":1}`))
+	s.PutPending("sms-2 https://a.example/", []byte(`{"attempt":3}`))
+	s.DeletePending("sms-1 https://a.example/")
+	s.Close()
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || string(got["sms-2 https://a.example/"]) != `{"attempt":3}` {
+		t.Errorf("Pending = %q, want only sms-2", got)
+	}
+}
@@ -0,0 +1,283 @@
+package ints
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// Wrapper for JSON Response
+type ApiResponse struct {
+	SEcho                interface{}     `json:"sEcho"`
+	ITotalRecords        interface{}     `json:"iTotalRecords"`
+	ITotalDisplayRecords interface{}     `json:"iTotalDisplayRecords"`
+	AAData               [][]interface{} `json:"aaData"`
+	Provider             string          `json:"provider,omitempty"` // Panel name (e.g. "number-panel")
+	Account              string          `json:"account,omitempty"`  // Login the rows belong to
+}
+
+// SMSLayout: Where each field sits in a raw data_smscdr.php row.
+// Date, Range, Number and Sender are always columns 0-3.
+//
+//	client (d-group):    [Date, Range, Number, Sender, Message, Currency, Cost]
+//	client (numberpanel):[Date, Range, Number, Sender, Message, Cost]
+//	agent  (npm-neon):   [Date, Country, Number, Service, User, Message, Cost, Status]
+//	agent  (mait):       [Date, Range, Number, Service, User, Message, Currency, Cost, Status]
+type SMSLayout struct {
+	Message  int
+	Currency int // -1: not sent by panel, "$" is used
+	Cost     int // -1: not sent by panel, "0" is used
+	Status   int // -1: no status column in the output
+
+	TrimHash bool // Legacy output drops "<#>" and outer space (npm-neon always did)
+}
+
+// NumberLayout: Where each field sits in a raw data_smsnumbers.php row.
+//
+//	client: [Range, Prefix(empty), Number, Period, Price, Stats]
+//	agent:  [Checkbox, Range, Prefix, Number, PriceHTML, Action, Empty, Stats]
+type NumberLayout struct {
+	Range  int
+	Prefix int // -1: calling code is derived from the number
+	Number int
+	Period int // -1: period and currency are parsed out of the price HTML
+	Price  int
+	Stats  int
+
+	StripBold bool // Legacy output drops <b></b> around Stats (number-panel always did)
+}
+
+// Shared number layouts (SMS layouts differ per panel, see each package)
+var (
+	ClientNumbers = NumberLayout{Range: 0, Prefix: -1, Number: 2, Period: 3, Price: 4, Stats: 5}
+	AgentNumbers  = NumberLayout{Range: 1, Prefix: 2, Number: 3, Period: -1, Price: 4, Stats: 7}
+)
+
+func (l SMSLayout) columns() int {
+	return maxInt(l.Message, l.Currency, l.Cost, l.Status, 6) + 1
+}
+
+func (l NumberLayout) columns() int {
+	return maxInt(l.Range, l.Prefix, l.Number, l.Period, l.Price, l.Stats) + 1
+}
+
+func maxInt(vals ...int) int {
+	m := vals[0]
+	for _, v := range vals[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// cell: row[i] or def when the column is missing / not configured
+func cell(row []interface{}, i int, def interface{}) interface{} {
+	if i < 0 || i >= len(row) || row[i] == nil {
+		return def
+	}
+	return row[i]
+}
+
+func cellString(row []interface{}, i int) string {
+	s, _ := cell(row, i, "").(string)
+	return s
+}
+
+// cellText: Like cellString but also renders JSON numbers (some panels send cost as 0.01)
+func cellText(row []interface{}, i int) string {
+	switch v := cell(row, i, "").(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// ---------------------- SMS CLEANING ----------------------
+
+// cleanSMS output: [Date, Range, Number, Service, Msg, Currency, Cost(, Status)]
+func cleanSMS(rawJSON []byte, l SMSLayout, name, account string) ([]byte, error) {
+	var apiResp ApiResponse
+	if err := json.Unmarshal(rawJSON, &apiResp); err != nil {
+		return rawJSON, nil
+	}
+	apiResp.Provider, apiResp.Account = name, account
+
+	var cleanedRows [][]interface{}
+	for _, row := range apiResp.AAData {
+		if len(row) <= l.Message {
+			continue
+		}
+
+		msg := legacyMessage(cellString(row, l.Message), l.TrimHash)
+
+		newRow := []interface{}{
+			row[0],                     // Date
+			row[1],                     // Range / Country
+			row[2],                     // Number
+			row[3],                     // Service / Sender
+			msg,                        // Full Message
+			cell(row, l.Currency, "$"), // Currency
+			cell(row, l.Cost, "0"),     // Cost
+		}
+		if l.Status >= 0 {
+			newRow = append(newRow, cell(row, l.Status, "")) // Status
+		}
+		cleanedRows = append(cleanedRows, newRow)
+	}
+	apiResp.AAData = cleanedRows
+	return json.Marshal(apiResp)
+}
+
+// legacyMessage: The message exactly as the old per-panel cleaners sent it,
+// "null" removed anywhere (existing clients rely on these bytes)
+func legacyMessage(msg string, trimHash bool) string {
+	msg = html.UnescapeString(msg)
+	if trimHash {
+		msg = strings.ReplaceAll(msg, "<#>", "")
+	}
+	msg = strings.ReplaceAll(msg, "null", "")
+	if trimHash {
+		msg = strings.TrimSpace(msg)
+	}
+	return msg
+}
+
+// cleanMessage: v2 message. Unescaped, without the "<#>" app hash marker;
+// a panel "null" is only dropped when it is the whole value (or a whole
+// line, some panels append one), so words like "annulled" survive.
+func cleanMessage(msg string) string {
+	msg = strings.ReplaceAll(html.UnescapeString(msg), "<#>", "")
+	lines := strings.Split(msg, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.EqualFold(strings.TrimSpace(line), "null") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// ---------------------- NUMBERS CLEANING ----------------------
+
+var rePrice = regexp.MustCompile(`[\d\.]+`)
+
+// cleanNumbers output: [Range, Country Code / Prefix, Number, Period, Price, Stats]
+func cleanNumbers(rawJSON []byte, l NumberLayout, name, account string) ([]byte, error) {
+	var apiResp ApiResponse
+	if err := json.Unmarshal(rawJSON, &apiResp); err != nil {
+		return rawJSON, nil
+	}
+	apiResp.Provider, apiResp.Account = name, account
+
+	var processedRows [][]interface{}
+	for _, row := range apiResp.AAData {
+		if len(row) < l.columns() {
+			continue
+		}
+
+		// 1. Clean Number (Remove spaces/dashes)
+		numberStr := cellString(row, l.Number)
+		numberStr = strings.ReplaceAll(numberStr, " ", "")
+		numberStr = strings.ReplaceAll(numberStr, "-", "")
+
+		// 2. Country Code (client panels leave the prefix column empty)
+		prefix := cell(row, l.Prefix, "")
+		if l.Prefix < 0 {
+			prefix = countryCode(numberStr)
+		}
+
+		// 3. Period + Price
+		period := cellString(row, l.Period)
+		price := cellString(row, l.Price)
+		if l.Period < 0 {
+			period, price = parsePriceHTML(price)
+		}
+
+		// 4. Clean Stats HTML
+		stats := cell(row, l.Stats, "")
+		if s, ok := stats.(string); ok && l.StripBold {
+			stats = cleanStats(s)
+		}
+
+		newRow := []interface{}{
+			cell(row, l.Range, ""), // [0] Main Title (e.g. Algeria-Exclusive...)
+			prefix,                 // [1] Country Code (e.g. 213)
+			numberStr,              // [2] Full Number
+			period,                 // [3] Period (Weekly/Monthly)
+			price,                  // [4] Price
+			stats,                  // [5] Bottom Stats
+		}
+		processedRows = append(processedRows, newRow)
+	}
+
+	apiResp.AAData = processedRows
+	apiResp.ITotalRecords = len(processedRows)
+	apiResp.ITotalDisplayRecords = len(processedRows)
+	return json.Marshal(apiResp)
+}
+
+func cleanStats(s string) string {
+	s = strings.ReplaceAll(s, "<b>", "")
+	s = strings.ReplaceAll(s, "</b>", "")
+	return strings.TrimSpace(s)
+}
+
+// regionCode: ISO 3166 alpha-2 of a number ("DZ"), empty if unknown
+func regionCode(number string) string {
+	parseNumStr := number
+	if !strings.HasPrefix(parseNumStr, "+") {
+		parseNumStr = "+" + parseNumStr
+	}
+	numObj, err := phonenumbers.Parse(parseNumStr, "")
+	if err != nil {
+		return ""
+	}
+	return phonenumbers.GetRegionCodeForNumber(numObj)
+}
+
+// countryCode: Calling code via libphonenumber, first 3 digits as fallback
+func countryCode(number string) string {
+	parseNumStr := number
+	if !strings.HasPrefix(parseNumStr, "+") {
+		parseNumStr = "+" + parseNumStr
+	}
+	numObj, err := phonenumbers.Parse(parseNumStr, "")
+	if err == nil {
+		return strconv.Itoa(int(numObj.GetCountryCode()))
+	}
+	if len(number) > 3 {
+		return number[:3]
+	}
+	return ""
+}
+
+// parsePriceHTML: Agent panels render "<b>$ 0.01</b> Weekly" style HTML
+func parsePriceHTML(priceHTML string) (billingType, price string) {
+	billingType = "Weekly"
+	if strings.Contains(strings.ToLower(priceHTML), "monthly") {
+		billingType = "Monthly"
+	}
+
+	currency := "$"
+	if strings.Contains(priceHTML, "€") {
+		currency = "€"
+	} else if strings.Contains(priceHTML, "£") {
+		currency = "£"
+	}
+
+	priceVal := "0"
+	matches := rePrice.FindAllString(priceHTML, -1)
+	if len(matches) > 0 {
+		priceVal = matches[len(matches)-1]
+	}
+	return billingType, currency + " " + priceVal
+}
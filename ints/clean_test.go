@@ -0,0 +1,60 @@
+package ints
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLegacyMessage(t *testing.T) {
+	tests := []struct {
+		in       string
+		trimHash bool
+		want     string
+	}{
+		// The bytes the old per-panel cleaners sent, kept for existing clients
+		{"<#> Code 1234\nnull", false, "<#> Code 1234\n"},
+		{"  Code &amp; 1234 ", false, "  Code & 1234 "},
+		{"<#> Code 1234\nnull", true, "Code 1234"}, // npm-neon
+		{"&lt;#&gt; Code 1234 ", true, "Code 1234"},
+		{"Order annulled", false, "Order aned"}, // Old behaviour, v2 fixes it
+	}
+	for _, tt := range tests {
+		if got := legacyMessage(tt.in, tt.trimHash); got != tt.want {
+			t.Errorf("legacyMessage(%q, %v) = %q, want %q", tt.in, tt.trimHash, got, tt.want)
+		}
+	}
+}
+
+func TestCleanMessage(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"null", ""},
+		{" NULL ", ""},
+		{"Your code 482-913\nnull", "Your code 482-913"},
+		{"Order annulled, code 1234", "Order annulled, code 1234"},
+		{"nullify 5678", "nullify 5678"},
+		{"<#> G-731094 is your code.", "G-731094 is your code."},
+		{"Tom &amp; Jerry 1234", "Tom & Jerry 1234"},
+	}
+	for _, tt := range tests {
+		if got := cleanMessage(tt.in); got != tt.want {
+			t.Errorf("cleanMessage(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Only number-panel stripped <b> from the stats column
+func TestLegacyStats(t *testing.T) {
+	raw := []byte(`{"aaData":[["Algeria","","213551234567","Weekly","0.5","<b>12</b> SMS "]]}`)
+	for strip, want := range map[bool]string{false: "<b>12</b> SMS ", true: "12 SMS"} {
+		l := ClientNumbers
+		l.StripBold = strip
+		out, _ := cleanNumbers(raw, l, "test", "")
+		var resp ApiResponse
+		if err := json.Unmarshal(out, &resp); err != nil || len(resp.AAData) != 1 {
+			t.Fatalf("StripBold %v: %s, %v", strip, out, err)
+		}
+		if got := resp.AAData[0][5]; got != want {
+			t.Errorf("StripBold %v: stats %q, want %q", strip, got, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ints
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"myproject/captcha"
+	"myproject/config"
+)
+
+// =========================================================
+// BUILT-IN DEFINITIONS (Panel packages register from init)
+// Definitions carry the panel shape; URLs and logins come from config.
+// =========================================================
+var (
+	definitions = map[string]Config{}
+	defMutex    sync.RWMutex
+)
+
+// Define registers a panel shape under its Name.
+func Define(cfg Config) {
+	defMutex.Lock()
+	defer defMutex.Unlock()
+
+	if _, exists := definitions[cfg.Name]; exists {
+		panic(fmt.Sprintf("ints: definition %q registered twice", cfg.Name))
+	}
+	definitions[cfg.Name] = cfg
+}
+
+func Definition(name string) (Config, bool) {
+	defMutex.RLock()
+	defer defMutex.RUnlock()
+
+	cfg, ok := definitions[name]
+	return cfg, ok
+}
+
+// Names: Every built-in definition, sorted
+func Names() []string {
+	defMutex.RLock()
+	defer defMutex.RUnlock()
+
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build creates a panel from a config file entry on top of its definition.
+func Build(p config.Panel) (*Panel, error) {
+	template := p.Template
+	if template == "" {
+		template = p.Name
+	}
+	cfg, ok := Definition(template)
+	if !ok {
+		return nil, fmt.Errorf("panel %q: unknown template %q", p.Name, template)
+	}
+
+	cfg.Name = p.Name
+	if p.Template != "" && p.Template != p.Name {
+		cfg.Tag = cfg.Tag + ":" + p.Name
+	}
+	if p.BaseURL != "" {
+		cfg.BaseURL = p.BaseURL
+	}
+	if p.Path != "" {
+		cfg.Path = p.Path
+	}
+	if p.Role != "" {
+		cfg.Role = p.Role
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("panel %q: base_url missing", p.Name)
+	}
+	if p.Captcha != "" {
+		solver, ok := captcha.Get(p.Captcha)
+		if !ok {
+			return nil, fmt.Errorf("panel %q: unknown captcha solver %q (have %v)", p.Name, p.Captcha, captcha.Names())
+		}
+		cfg.Captcha = solver
+	}
+
+	var logins []Login
+	seen := map[string]bool{}
+	for _, a := range p.Logins() {
+		if a.Username == "" || a.Password == "" {
+			return nil, errors.New("panel " + p.Name + ": username/password missing")
+		}
+		if seen[a.Username] {
+			return nil, fmt.Errorf("panel %q: account %q listed twice", p.Name, a.Username)
+		}
+		seen[a.Username] = true
+		logins = append(logins, Login{Username: a.Username, Password: a.Password})
+	}
+	if len(logins) == 0 {
+		return nil, errors.New("panel " + p.Name + ": no accounts configured")
+	}
+	return NewPanel(cfg, logins), nil
+}
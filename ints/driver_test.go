@@ -0,0 +1,223 @@
+package ints_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"myproject/dgroup"
+	"myproject/ints"
+	"myproject/mait"
+	"myproject/mockpanel"
+	"myproject/npmneon"
+	"myproject/numberpanel"
+	"myproject/provider"
+)
+
+// Every built-in driver against a mock with its own column layout: the
+// newest SMS and the numbers must come out typed correctly
+func TestDrivers(t *testing.T) {
+	for _, tc := range []struct {
+		cfg      ints.Config
+		number   string
+		service  string
+		currency string
+		cost     float64
+		status   string
+		code     string
+		numbers  int
+	}{
+		{dgroup.Config, "213551234567", "WhatsApp", "$", 0.01, "", "482913", 2},
+		{mait.Config, "213551234567", "WhatsApp", "USD", 0.01, "Paid", "482913", 2},
+		{npmneon.Config, "213551234567", "WhatsApp", "$", 0.01, "Paid", "482913", 2},
+		{numberpanel.Config, "201001234567", "Google", "$", 0.02, "", "731094", 2},
+	} {
+		t.Run(tc.cfg.Name, func(t *testing.T) {
+			c, srv := startMock(t, tc.cfg, mockpanel.Options{})
+
+			records, err := c.FetchSMS()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) == 0 {
+				t.Fatal("no SMS records")
+			}
+			rec := records[0]
+			if rec.Number != tc.number || rec.Service != tc.service || rec.Currency != tc.currency ||
+				rec.Cost != tc.cost || rec.Status != tc.status || rec.Provider != tc.cfg.Name {
+				t.Errorf("newest record = %+v", rec)
+			}
+			if rec.Time.IsZero() {
+				t.Error("record time not parsed")
+			}
+			if rec.OTP == nil || rec.OTP.Code != tc.code {
+				t.Errorf("otp = %+v, want %s", rec.OTP, tc.code)
+			}
+
+			// Legacy view: [Date, Range, Number, Service, Message, Currency, Cost, (Status)]
+			raw, err := c.GetSMSLogs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var legacy struct {
+				AAData [][]any `json:"aaData"`
+			}
+			if err := json.Unmarshal(raw, &legacy); err != nil {
+				t.Fatalf("legacy SMS JSON: %v", err)
+			}
+			if len(legacy.AAData) != len(records) {
+				t.Fatalf("legacy rows = %d, records = %d", len(legacy.AAData), len(records))
+			}
+			row := legacy.AAData[0]
+			if fmt.Sprint(row[5]) != tc.currency || fmt.Sprint(row[6]) != fmt.Sprint(tc.cost) {
+				t.Errorf("legacy currency/cost = %v / %v", row[5], row[6])
+			}
+
+			numbers, err := c.FetchNumbers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(numbers) != tc.numbers {
+				t.Fatalf("got %d numbers, want %d", len(numbers), tc.numbers)
+			}
+			for _, n := range numbers {
+				if n.Number == "" || n.Price <= 0 || n.Provider != tc.cfg.Name {
+					t.Errorf("number = %+v", n)
+				}
+			}
+
+			if st := srv.Stats(); st.Logins != 1 || st.FailedLogins != 0 {
+				t.Errorf("stats = %+v, want one clean login", st)
+			}
+		})
+	}
+}
+
+func TestDriverRelogin(t *testing.T) {
+	c, srv := startMock(t, dgroup.Config, mockpanel.Options{})
+	if _, err := c.FetchSMS(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ExpireSessions()
+	if _, err := c.FetchSMS(); err != nil {
+		t.Fatalf("after expiry: %v", err)
+	}
+	if st := srv.Stats(); st.Logins != 2 || st.Rejected != 1 {
+		t.Errorf("stats = %+v, want a second login after one rejected call", st)
+	}
+
+	// One HTML error page is retried after a re-login, two in a row are not
+	srv.FailNext(1)
+	if _, err := c.FetchSMS(); err != nil {
+		t.Errorf("one error page: %v", err)
+	}
+	srv.FailNext(2)
+	if _, err := c.FetchSMS(); !errors.Is(err, provider.ErrSessionExpired) {
+		t.Errorf("two error pages: err = %v, want ErrSessionExpired", err)
+	}
+}
+
+func TestDriverBlocked(t *testing.T) {
+	cfg := mait.Config
+	cfg.Name = "mait-blocked-test" // Breakers are global, per name
+	c, srv := startMock(t, cfg, mockpanel.Options{})
+	if _, err := c.FetchSMS(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetBlocked(true)
+	_, err := c.FetchSMS()
+	var blocked *provider.ErrBlocked
+	if !errors.As(err, &blocked) {
+		t.Fatalf("err = %v, want ErrBlocked", err)
+	}
+
+	// The breaker holds further calls back instead of hitting the panel
+	before := srv.Stats().DataRequests
+	srv.SetBlocked(false)
+	if _, err := c.FetchSMS(); !errors.As(err, &blocked) {
+		t.Errorf("while open: err = %v, want ErrBlocked", err)
+	} else if blocked.Reason != "circuit_open" || blocked.RetryAfter <= 0 {
+		t.Errorf("while open: %+v, want circuit_open with a RetryAfter", blocked)
+	}
+	if after := srv.Stats().DataRequests; after != before {
+		t.Errorf("%d requests reached the panel while the circuit was open", after-before)
+	}
+}
+
+// fnum/fcli are sent to the panel; a panel that ignores them returns
+// every row unless the driver lists them in LocalFilters
+func TestUpstreamFilters(t *testing.T) {
+	for _, cfg := range []ints.Config{mait.Config, npmneon.Config} {
+		t.Run(cfg.Name, func(t *testing.T) {
+			c, srv := startMock(t, cfg, mockpanel.Options{})
+			for _, q := range []provider.SMSQuery{
+				{Number: "+92 300 1234567"},
+				{Sender: "whatsapp"},
+			} {
+				before := srv.Stats().DataRequests
+				feed, err := c.QuerySMS(q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(feed.Records) != 1 {
+					t.Errorf("%+v: got %d rows, want 1", q, len(feed.Records))
+				}
+				if n := srv.Stats().DataRequests - before; n != 1 {
+					t.Errorf("%+v: %d upstream requests, want 1", q, n)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalFilters(t *testing.T) {
+	now := time.Now().In(provider.PanelLocation)
+	rows := mockpanel.AgentSMS(now)
+	for i := 0; i < 250; i++ {
+		at := now.Add(-20*time.Minute - time.Duration(i)*time.Second).Format("2006-01-02 15:04:05")
+		rows = append(rows, []any{at, "Filler", "100000000" + fmt.Sprint(i), "INFO", "client01", "filler", "USD", "0", "Paid"})
+	}
+
+	cfg := mait.Config
+	cfg.LocalFilters = ints.FilterNumber | ints.FilterSender
+	ignore := []string{"fnum", "fcli"} // Set apart from cfg, like a panel that drops them
+
+	c, srv := startMock(t, cfg, mockpanel.Options{SMS: rows, Ignore: ignore})
+	for _, q := range []provider.SMSQuery{
+		{Number: "+92 300 1234567"},
+		{Sender: "whatsapp"},
+	} {
+		before := srv.Stats().DataRequests
+		feed, err := c.QuerySMS(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(feed.Records) != 1 {
+			t.Errorf("%+v: got %d rows, want 1", q, len(feed.Records))
+		}
+		for _, rec := range feed.Records {
+			if (q.Number != "" && rec.Number != "923001234567") || (q.Sender != "" && rec.Service != "WhatsApp") {
+				t.Errorf("%+v: unfiltered row %+v", q, rec)
+			}
+		}
+		// Only the requested page is fetched and filtered, never the whole range
+		if n := srv.Stats().DataRequests - before; n != 1 {
+			t.Errorf("%+v: %d upstream requests, want 1", q, n)
+		}
+	}
+
+	// Without LocalFilters the ignoring panel hands back the whole page
+	cfg.LocalFilters = 0
+	c, _ = startMock(t, cfg, mockpanel.Options{SMS: rows, Ignore: ignore})
+	feed, err := c.QuerySMS(provider.SMSQuery{Sender: "whatsapp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Records) != cfg.SMSPageSize {
+		t.Errorf("unfiltered: got %d rows, want a full page of %d", len(feed.Records), cfg.SMSPageSize)
+	}
+}
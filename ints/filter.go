@@ -0,0 +1,118 @@
+package ints
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"myproject/provider"
+)
+
+// ---------------------------------------------------------
+// SMS FILTERS (?number= ?range= ?sender= ?q=)
+// ---------------------------------------------------------
+
+// Filter: One of the data_smscdr.php search params
+type Filter int
+
+const (
+	FilterNumber Filter = 1 << iota // fnum
+	FilterRange                     // frange
+	FilterSender                    // fcli
+	FilterSearch                    // sSearch
+)
+
+// wanted: Filters set on q
+func wanted(q provider.SMSQuery) Filter {
+	var f Filter
+	if q.Number != "" {
+		f |= FilterNumber
+	}
+	if q.Range != "" {
+		f |= FilterRange
+	}
+	if q.Sender != "" {
+		f |= FilterSender
+	}
+	if q.Text != "" {
+		f |= FilterSearch
+	}
+	return f
+}
+
+// setFilters: Forwards the filters the panel handles itself
+func (c *Client) setFilters(params url.Values, q provider.SMSQuery) {
+	server := wanted(q) &^ c.LocalFilters
+	set := func(f Filter, key, value string) {
+		if server&f != 0 {
+			params.Set(key, value)
+		}
+	}
+	set(FilterNumber, "fnum", digits(q.Number))
+	set(FilterRange, "frange", q.Range)
+	set(FilterSender, "fcli", q.Sender)
+	set(FilterSearch, "sSearch", q.Text)
+}
+
+// filterSMS: Applies the local filters to the page the panel returned, so
+// the result can come back short. Nothing extra is fetched: pulling more
+// rows to fill the page would hammer panels that ban IPs. Non-JSON is
+// passed through for parseSMS to report.
+func filterSMS(body []byte, q provider.SMSQuery, local Filter) []byte {
+	var resp ApiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return body
+	}
+
+	rows := [][]interface{}{}
+	for _, row := range resp.AAData {
+		if matchRow(row, q, local) {
+			rows = append(rows, row)
+		}
+	}
+	resp.ITotalDisplayRecords = len(rows)
+	resp.AAData = rows
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// matchRow: Same rules as the panel: substrings, case-insensitive;
+// the number is compared on digits only
+func matchRow(row []interface{}, q provider.SMSQuery, local Filter) bool {
+	if local&FilterNumber != 0 && !strings.Contains(digits(cellText(row, 2)), digits(q.Number)) {
+		return false
+	}
+	if local&FilterRange != 0 && !containsFold(cellText(row, 1), q.Range) {
+		return false
+	}
+	if local&FilterSender != 0 && !containsFold(cellText(row, 3), q.Sender) {
+		return false
+	}
+	if local&FilterSearch != 0 {
+		for i := range row {
+			if containsFold(cellText(row, i), q.Text) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,579 @@
+package ints
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"myproject/breaker"
+	"myproject/captcha"
+	"myproject/provider"
+	"myproject/session"
+)
+
+// Generic driver for the IMS-style PHP panel (/ints/login, res/data_smscdr.php ...).
+// Every panel we use is the same software; only the values in Config differ.
+
+// TokenKind: How the panel authenticates AJAX calls after login
+type TokenKind int
+
+const (
+	TokenCookie  TokenKind = iota // Cookie jar only (npm-neon)
+	TokenSessKey                  // sesskey=... scraped from the reports page (client panels)
+	TokenCsstr                    // csstr=... scraped from the reports page (mait)
+)
+
+// String: "cookie", "sesskey" or "csstr", as mockpanel.Options.Auth
+func (k TokenKind) String() string {
+	switch k {
+	case TokenSessKey:
+		return "sesskey"
+	case TokenCsstr:
+		return "csstr"
+	}
+	return "cookie"
+}
+
+// Window: Date range sent as fdate1/fdate2 for the SMS CDR
+type Window int
+
+const (
+	WindowToday Window = iota // 00:00:00 - 23:59:59 today
+	WindowWide                // Fixed wide range (what the NumberPanel Node.js client sends)
+)
+
+// cookieMode: Placeholder token for panels where sesskey is optional
+const cookieMode = "cookie_mode"
+
+type Config struct {
+	Name     string // URL name, e.g. "d-group"
+	Tag      string // Log prefix, e.g. "D-Group"
+	BaseURL  string // e.g. "http://139.99.63.204"
+	Path     string // "/ints" or "/NumberPanel"
+	Role     string // "client" or "agent"
+	Username string
+	Password string
+
+	Auth          TokenKind
+	TokenOptional bool   // Fall back to cookies when the token is not on the page
+	ReportsPage   string // Page holding the token, relative to Role (e.g. "SMSCDRStats")
+	UserAgent     string
+
+	SMSWindow    Window
+	SMSPageSize  int    // iDisplayLength (-1 = all rows)
+	LocalFilters Filter // Search params a recorded response shows the panel ignores; the returned page is filtered here
+	SMSLayout    SMSLayout
+	NumberLayout NumberLayout
+
+	BlockCooldown time.Duration // Minimum pause after an IP block (403/Forbidden), on top of the breaker's backoff
+
+	Captcha captcha.Solver // nil: captcha.Math
+}
+
+type Client struct {
+	Config
+	HTTPClient *http.Client
+	Token      string // sesskey / csstr (cookieMode when optional and missing)
+	Mutex      sync.Mutex
+
+	breaker  *breaker.Breaker // Shared by every account of the panel
+	loggedIn atomic.Bool      // Mirror of hasSession for Health, which can't wait for Mutex
+	sessions *session.Store   // nil: sessions live in RAM only
+	restored bool             // Session came from disk and hasn't been used yet
+}
+
+func New(cfg Config) *Client {
+	jar, _ := cookiejar.New(nil)
+	b := breaker.Get(cfg.Name)
+	return &Client{
+		Config: cfg,
+		HTTPClient: &http.Client{
+			Jar:       jar,
+			Timeout:   60 * time.Second,
+			Transport: &breaker.Transport{Breaker: b},
+		},
+		breaker: b,
+	}
+}
+
+// ---------------------------------------------------------
+// URL HELPERS
+// ---------------------------------------------------------
+
+// panelURL: BaseURL + Path + "/" + page   (login, signin)
+func (c *Client) panelURL(page string) string {
+	return c.BaseURL + c.Path + "/" + page
+}
+
+// roleURL: BaseURL + Path + "/" + Role + "/" + page   (reports, res/*.php)
+func (c *Client) roleURL(page string) string {
+	return c.BaseURL + c.Path + "/" + c.Role + "/" + page
+}
+
+func (c *Client) setCommonHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Connection", "keep-alive")
+}
+
+func (c *Client) logf(format string, args ...interface{}) {
+	fmt.Printf("["+c.Tag+"] "+format+"\n", args...)
+}
+
+// ---------------------------------------------------------
+// PROVIDER INTERFACE
+// ---------------------------------------------------------
+
+func (c *Client) Name() string { return c.Config.Name }
+
+// Login: Drops the current session and logs in again
+func (c *Client) Login() error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	if err := c.checkBlock(); err != nil {
+		return err
+	}
+	c.resetSession()
+	return c.login()
+}
+
+func (c *Client) Health() provider.Health {
+	return provider.Health{Name: c.Name(), LoggedIn: c.loggedIn.Load(), Blocked: c.breaker.IsOpen()}
+}
+
+// ---------------------------------------------------------
+// SESSION / LOGIN LOGIC
+// ---------------------------------------------------------
+
+// hasSession: Token / cookie jar check, Mutex held
+func (c *Client) hasSession() bool {
+	if c.Auth == TokenCookie {
+		u, _ := url.Parse(c.BaseURL)
+		return len(c.HTTPClient.Jar.Cookies(u)) > 0
+	}
+	return c.Token != ""
+}
+
+func (c *Client) resetSession() {
+	c.Token = ""
+	c.HTTPClient.Jar, _ = cookiejar.New(nil)
+	c.loggedIn.Store(false)
+	c.forgetSession()
+}
+
+// checkBlock: Returns an error while the panel's circuit is open
+func (c *Client) checkBlock() error {
+	return c.upstream(c.breaker.Check())
+}
+
+// markBlocked: IP block seen in a page body, opens the circuit
+func (c *Client) markBlocked(reason string) error {
+	retry := c.breaker.Trip("IP blocked ("+reason+")", c.BlockCooldown)
+	c.logf("🚨 IP BLOCKED (%s). Cooling down for %s", reason, retry.Round(time.Second))
+	return &provider.ErrBlocked{Provider: c.Name(), Reason: reason, RetryAfter: retry}
+}
+
+// upstream: Transport errors as provider errors; an open circuit is
+// reported as blocked
+func (c *Client) upstream(err error) error {
+	var open *breaker.OpenError
+	if errors.As(err, &open) {
+		c.logf("⏳ Circuit open. Cooling down... Wait %ds", int(open.RetryAfter.Seconds()+0.999))
+		return &provider.ErrBlocked{Provider: c.Name(), Reason: "circuit_open", RetryAfter: open.RetryAfter}
+	}
+	return provider.Upstream(err)
+}
+
+func (c *Client) ensureSession() error {
+	if err := c.checkBlock(); err != nil {
+		return err
+	}
+	if c.hasSession() {
+		return nil
+	}
+	c.logf("Session missing, Login start...")
+	return c.login()
+}
+
+// login: performLogin, then save the session for the next boot
+func (c *Client) login() error {
+	if err := c.performLogin(); err != nil {
+		return err
+	}
+	c.loggedIn.Store(c.hasSession())
+	c.saveSession()
+	return nil
+}
+
+var (
+	sessRe   = regexp.MustCompile(`sesskey=([a-zA-Z0-9%=]+)`)
+	csstrRe  = regexp.MustCompile(`csstr=([a-zA-Z0-9]+)`)
+	csstrRe2 = regexp.MustCompile(`["']csstr["']\s*[:=]\s*["']?([^"']+)["']?`)
+)
+
+func (c *Client) performLogin() error {
+	// Step 1: Login Page
+	c.logf(">> Step 1: Login Page")
+	req, _ := http.NewRequest("GET", c.panelURL("login"), nil)
+	c.setCommonHeaders(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return c.upstream(err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	bodyString := string(bodyBytes)
+	c.record("login.html", bodyBytes)
+
+	if resp.StatusCode == 403 || strings.Contains(bodyString, "Forbidden") {
+		return c.markBlocked("403")
+	}
+
+	// Step 2: Solve Captcha
+	solver := c.Captcha
+	if solver == nil {
+		solver = captcha.Math{}
+	}
+	captchaAns, err := solver.Solve(bodyString)
+	if err != nil {
+		return fmt.Errorf("%w: %v", provider.ErrCaptcha, err)
+	}
+	c.logf(">> Step 2: Captcha Solved: %s", captchaAns)
+
+	// Step 3: Login POST
+	data := url.Values{}
+	data.Set("username", c.Username)
+	data.Set("password", c.Password)
+	data.Set("capt", captchaAns)
+
+	loginReq, _ := http.NewRequest("POST", c.panelURL("signin"), bytes.NewBufferString(data.Encode()))
+	c.setCommonHeaders(loginReq)
+	loginReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	loginReq.Header.Set("Referer", c.panelURL("login"))
+	loginReq.Header.Set("Origin", c.BaseURL)
+
+	resp, err = c.HTTPClient.Do(loginReq)
+	if err != nil {
+		return c.upstream(err)
+	}
+	defer resp.Body.Close()
+
+	if c.Auth == TokenCookie {
+		if !c.hasSession() {
+			return fmt.Errorf("%w: no cookies received", provider.ErrAuthFailed)
+		}
+		c.logf("Login Successful! Session Saved to RAM.")
+		return nil
+	}
+
+	// Step 4: Token from the reports page
+	c.logf(">> Step 3: Getting token from %s", c.ReportsPage)
+	reportReq, _ := http.NewRequest("GET", c.roleURL(c.ReportsPage), nil)
+	c.setCommonHeaders(reportReq)
+	reportReq.Header.Set("Referer", c.roleURL("SMSDashboard"))
+
+	resp, err = c.HTTPClient.Do(reportReq)
+	if err != nil {
+		return c.upstream(err)
+	}
+	defer resp.Body.Close()
+	reportBody, _ := io.ReadAll(resp.Body)
+	reportString := string(reportBody)
+
+	token := ""
+	if c.Auth == TokenSessKey {
+		if m := sessRe.FindStringSubmatch(reportString); len(m) > 1 {
+			token = m[1]
+		}
+	} else {
+		if m := csstrRe.FindStringSubmatch(reportString); len(m) > 1 {
+			token = m[1]
+		} else if m := csstrRe2.FindStringSubmatch(reportString); len(m) > 1 {
+			token = m[1]
+		}
+	}
+
+	switch {
+	case token != "":
+		c.Token = token // Save to RAM
+		c.record("reports.html", reportBody)
+		c.logf("✅ LOGIN SUCCESS. Token saved (%d chars)", len(c.Token))
+	case strings.Contains(reportString, "Forbidden"):
+		return c.markBlocked("at_reports")
+	case c.TokenOptional:
+		// Some client panels rely on cookies only
+		c.logf("Warning: token not found, using Cookies only.")
+		c.Token = cookieMode
+	default:
+		return fmt.Errorf("%w: token not found on %s", provider.ErrAuthFailed, c.ReportsPage)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------
+// AJAX FETCH (Auto Re-login Loop)
+// ---------------------------------------------------------
+
+func isHTML(body []byte) bool {
+	lower := bytes.ToLower(body)
+	return bytes.Contains(lower, []byte("<!doctype html")) || bytes.Contains(lower, []byte("<html"))
+}
+
+// fetch: GETs a res/*.php endpoint with the session token, re-logging once on expiry
+func (c *Client) fetch(endpoint, referer string, params url.Values) ([]byte, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	for i := 0; i < 2; i++ {
+		if err := c.ensureSession(); err != nil {
+			if i == 0 && !c.breaker.IsOpen() {
+				c.resetSession()
+				continue
+			}
+			return nil, err
+		}
+
+		switch c.Auth {
+		case TokenSessKey:
+			if c.Token != cookieMode {
+				params.Set("sesskey", c.Token)
+			}
+		case TokenCsstr:
+			params.Set("csstr", c.Token)
+		}
+
+		req, _ := http.NewRequest("GET", c.roleURL(endpoint)+"?"+params.Encode(), nil)
+		c.setCommonHeaders(req)
+		req.Header.Set("X-Requested-With", "XMLHttpRequest")
+		req.Header.Set("Accept", "application/json, text/javascript, */*; q=0.01")
+		req.Header.Set("Referer", referer)
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, c.upstream(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		// CHECK: Session Expired / Blocked (HTML received)
+		if isHTML(body) {
+			if bytes.Contains(body, []byte("Forbidden")) {
+				return nil, c.markBlocked("api")
+			}
+			c.logf("Session Expired (HTML). Re-logging...")
+			c.resetSession()
+			continue
+		}
+		if c.restored {
+			c.logf("✅ Restored session is valid, no login needed")
+			c.restored = false
+		}
+		c.record(strings.TrimSuffix(path.Base(endpoint), ".php")+".json", body)
+		return body, nil
+	}
+	return nil, fmt.Errorf("%s: %w", c.Name(), provider.ErrSessionExpired)
+}
+
+// dataTablesParams: The column boilerplate every data_*.php endpoint expects
+func dataTablesParams(echo string, columns int) url.Values {
+	params := url.Values{}
+	params.Set("sEcho", echo)
+	params.Set("iColumns", strconv.Itoa(columns))
+	params.Set("sColumns", strings.Repeat(",", columns-1))
+	params.Set("iDisplayStart", "0")
+	params.Set("sSearch", "")
+	params.Set("bRegex", "false")
+	params.Set("iSortingCols", "1")
+	params.Set("iSortCol_0", "0")
+
+	for j := 0; j < columns; j++ {
+		idx := strconv.Itoa(j)
+		params.Set("mDataProp_"+idx, idx)
+		params.Set("sSearch_"+idx, "")
+		params.Set("bRegex_"+idx, "false")
+		params.Set("bSearchable_"+idx, "true")
+		params.Set("bSortable_"+idx, "true")
+	}
+	return params
+}
+
+// ---------------------- SMS LOGIC ----------------------
+
+// smsWindow: fdate1/fdate2 for the configured window
+func (c *Client) smsWindow() (string, string) {
+	if c.SMSWindow == WindowToday {
+		today := time.Now().In(provider.PanelLocation).Format("2006-01-02")
+		return today + " 00:00:00", today + " 23:59:59"
+	}
+	return "2026-01-07 00:00:00", "2259-12-20 23:59:59"
+}
+
+// smsParams: data_smscdr.php filters for q (paging is set by smsRaw)
+func (c *Client) smsParams(q provider.SMSQuery) url.Values {
+	fdate1, fdate2 := c.smsWindow()
+	if !q.From.IsZero() {
+		fdate1 = q.From.In(provider.PanelLocation).Format(panelTimeLayout)
+	}
+	if !q.To.IsZero() {
+		fdate2 = q.To.In(provider.PanelLocation).Format(panelTimeLayout)
+	}
+	sortDir := "desc"
+	if q.Ascending() {
+		sortDir = "asc"
+	}
+
+	params := dataTablesParams("1", c.SMSLayout.columns())
+	params.Set("fdate1", fdate1)
+	params.Set("fdate2", fdate2)
+	params.Set("frange", "")
+	params.Set("fnum", "")
+	params.Set("fcli", "")
+	params.Set("fclient", "")
+	params.Set("fg", "0")
+	params.Set("sSortDir_0", sortDir)
+	c.setFilters(params, q)
+	return params
+}
+
+// smsRaw: Raw data_smscdr.php JSON. A limit above SMSPageSize is fetched
+// page by page and the rows joined into one DataTables response.
+func (c *Client) smsRaw(q provider.SMSQuery) ([]byte, error) {
+	params := c.smsParams(q)
+	if q.Limit > provider.MaxSMSLimit {
+		q.Limit = provider.MaxSMSLimit
+	}
+
+	if q.Limit <= 0 || c.SMSPageSize <= 0 || q.Limit <= c.SMSPageSize {
+		length := q.Limit
+		if length <= 0 {
+			length = c.SMSPageSize
+		}
+		params.Set("iDisplayStart", strconv.Itoa(q.Offset))
+		params.Set("iDisplayLength", strconv.Itoa(length))
+		return c.fetch("res/data_smscdr.php", c.roleURL(c.ReportsPage), params)
+	}
+
+	var out ApiResponse
+	start := q.Offset
+	for len(out.AAData) < q.Limit {
+		length := min(c.SMSPageSize, q.Limit-len(out.AAData))
+		params.Set("iDisplayStart", strconv.Itoa(start))
+		params.Set("iDisplayLength", strconv.Itoa(length))
+		body, err := c.fetch("res/data_smscdr.php", c.roleURL(c.ReportsPage), params)
+		if err != nil {
+			return nil, err
+		}
+
+		var page ApiResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("%s: %w: bad SMS JSON: %v", c.Name(), provider.ErrBadResponse, err)
+		}
+		out.SEcho, out.ITotalRecords, out.ITotalDisplayRecords = page.SEcho, page.ITotalRecords, page.ITotalDisplayRecords
+		out.AAData = append(out.AAData, page.AAData...)
+		start += len(page.AAData)
+
+		// Short page or nothing left to page through
+		if len(page.AAData) < length {
+			break
+		}
+		if total, ok := totalRows(page.ITotalDisplayRecords); ok && start >= total {
+			break
+		}
+	}
+	return json.Marshal(out)
+}
+
+// totalRows: iTotalDisplayRecords comes as a number or a string
+func totalRows(v interface{}) (int, bool) {
+	switch t := v.(type) {
+	case float64:
+		return int(t), true
+	case string:
+		n, err := strconv.Atoi(t)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// GetSMSLogs: Legacy DataTables view
+func (c *Client) GetSMSLogs() ([]byte, error) {
+	feed, err := c.PollSMS()
+	return feed.Legacy, err
+}
+
+// FetchSMS: Typed records (v2 API)
+func (c *Client) FetchSMS() ([]provider.SMSRecord, error) {
+	feed, err := c.PollSMS()
+	return feed.Records, err
+}
+
+// PollSMS: Both views from a single upstream fetch (used by the poller)
+func (c *Client) PollSMS() (provider.SMSFeed, error) {
+	return c.QuerySMS(provider.SMSQuery{})
+}
+
+// QuerySMS: Both views for a date range / page / sort order / filters.
+// Filters the panel can't run are applied to the fetched page.
+func (c *Client) QuerySMS(q provider.SMSQuery) (provider.SMSFeed, error) {
+	local := wanted(q) & c.LocalFilters
+	body, err := c.smsRaw(q)
+	if err != nil {
+		return provider.SMSFeed{}, err
+	}
+	if local != 0 {
+		body = filterSMS(body, q, local)
+	}
+	legacy, _ := cleanSMS(body, c.SMSLayout, c.Name(), c.Username)
+	records, err := parseSMS(body, c.SMSLayout, c.Name(), c.Username)
+	if err != nil {
+		return provider.SMSFeed{}, err
+	}
+	return provider.SMSFeed{Legacy: legacy, Records: records}, nil
+}
+
+// ---------------------- NUMBERS LOGIC (1st Jan to Today) ----------------------
+
+// numbersRaw: Raw data_smsnumbers.php JSON (all rows)
+func (c *Client) numbersRaw() ([]byte, error) {
+	params := dataTablesParams("2", c.NumberLayout.columns())
+	params.Set("fdate1", "2026-01-01 00:00:00")
+	params.Set("fdate2", time.Now().In(provider.PanelLocation).Format("2006-01-02")+" 23:59:59")
+	params.Set("frange", "")
+	params.Set("fclient", "")
+	params.Set("iDisplayLength", "-1") // Fetch All
+	params.Set("sSortDir_0", "asc")
+
+	return c.fetch("res/data_smsnumbers.php", c.roleURL("MySMSNumbers"), params)
+}
+
+// GetNumberStats: Legacy DataTables view
+func (c *Client) GetNumberStats() ([]byte, error) {
+	body, err := c.numbersRaw()
+	if err != nil {
+		return nil, err
+	}
+	return cleanNumbers(body, c.NumberLayout, c.Name(), c.Username)
+}
+
+// FetchNumbers: Typed inventory (v2 API)
+func (c *Client) FetchNumbers() ([]provider.NumberRecord, error) {
+	body, err := c.numbersRaw()
+	if err != nil {
+		return nil, err
+	}
+	return parseNumbers(body, c.NumberLayout, c.Name(), c.Username)
+}
@@ -0,0 +1,39 @@
+package ints_test
+
+import (
+	"testing"
+	"time"
+
+	"myproject/dgroup"
+	"myproject/mockpanel"
+)
+
+// Health is served while the poller re-logs in; run with -race
+func TestHealthDuringRelogin(t *testing.T) {
+	c, srv := startMock(t, dgroup.Config, mockpanel.Options{})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			srv.ExpireSessions()
+			if _, err := c.PollSMS(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for polling := true; polling; {
+		select {
+		case <-done:
+			polling = false
+		default:
+			c.Health()
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+
+	if !c.Health().LoggedIn {
+		t.Error("LoggedIn = false after a successful poll")
+	}
+}
@@ -0,0 +1,52 @@
+package ints_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"myproject/ints"
+)
+
+// The golden test reads column layouts from testdata/<panel>/layout.json, not
+// from the definitions; a definition that moves a column must fail here
+// instead of leaving the goldens pinned to a stale layout
+func TestRecordedLayouts(t *testing.T) {
+	dirs, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		def, ok := ints.Definition(d.Name())
+		if !ok {
+			t.Errorf("testdata/%s: no such panel definition", d.Name())
+			continue
+		}
+		seen[d.Name()] = true
+
+		data, err := os.ReadFile(filepath.Join("testdata", d.Name(), "layout.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ints.RecordedLayout
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("testdata/%s/layout.json: %v", d.Name(), err)
+		}
+		if got.SMS != def.SMSLayout {
+			t.Errorf("testdata/%s: SMS layout %+v, definition has %+v", d.Name(), got.SMS, def.SMSLayout)
+		}
+		if got.Numbers != def.NumberLayout {
+			t.Errorf("testdata/%s: number layout %+v, definition has %+v", d.Name(), got.Numbers, def.NumberLayout)
+		}
+	}
+	for _, name := range ints.Names() {
+		if !seen[name] {
+			t.Errorf("%s has no captures in testdata", name)
+		}
+	}
+}
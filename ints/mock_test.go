@@ -0,0 +1,21 @@
+package ints_test
+
+import (
+	"testing"
+
+	"myproject/ints"
+	"myproject/mockpanel"
+)
+
+// startMock: A mockpanel speaking cfg's login flow, and a Client pointed at it
+func startMock(t *testing.T, cfg ints.Config, opts mockpanel.Options) (*ints.Client, *mockpanel.Server) {
+	t.Helper()
+	opts.Panel, opts.Path, opts.Role, opts.ReportsPage = cfg.Name, cfg.Path, cfg.Role, cfg.ReportsPage
+	opts.Auth = cfg.Auth.String()
+	srv := mockpanel.Start(opts)
+	t.Cleanup(srv.Close)
+
+	cfg.BaseURL = srv.URL
+	cfg.Username, cfg.Password = "user", "pass"
+	return ints.New(cfg), srv
+}
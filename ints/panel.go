@@ -0,0 +1,319 @@
+package ints
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"myproject/provider"
+)
+
+// Login: One account on a panel
+type Login struct {
+	Username string
+	Password string
+}
+
+// Panel: One panel with N accounts. Each account is a full Client with its
+// own cookie jar and token. Panel is what gets registered as a provider.
+type Panel struct {
+	Config
+	accounts []*Client
+}
+
+func NewPanel(cfg Config, logins []Login) *Panel {
+	p := &Panel{Config: cfg}
+	for _, l := range logins {
+		acc := cfg
+		acc.Username, acc.Password = l.Username, l.Password
+		if len(logins) > 1 {
+			acc.Tag = cfg.Tag + "/" + l.Username
+		}
+		p.accounts = append(p.accounts, New(acc))
+	}
+	return p
+}
+
+// ---------------------------------------------------------
+// PROVIDER INTERFACE
+// ---------------------------------------------------------
+
+func (p *Panel) Name() string { return p.Config.Name }
+
+// Accounts: Usernames, usable as ?account=
+func (p *Panel) Accounts() []string {
+	names := make([]string, len(p.accounts))
+	for i, c := range p.accounts {
+		names[i] = c.Username
+	}
+	return names
+}
+
+// Account: The single-account provider behind ?account=<username>
+func (p *Panel) Account(name string) (provider.Provider, bool) {
+	for _, c := range p.accounts {
+		if c.Username == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Login: Re-logs every account, errors are joined
+func (p *Panel) Login() error {
+	var errs accountErrors
+	for _, c := range p.accounts {
+		if err := c.Login(); err != nil {
+			errs = append(errs, accountError{c.Username, err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// accountErrors: Per-account failures; errors.Is/As see every one of them,
+// so a blocked account still classifies as blocked
+type accountErrors []accountError
+
+type accountError struct {
+	username string
+	err      error
+}
+
+func (e accountErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, ae := range e {
+		parts[i] = ae.username + ": " + ae.err.Error()
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e accountErrors) Unwrap() []error {
+	out := make([]error, len(e))
+	for i, ae := range e {
+		out[i] = ae.err
+	}
+	return out
+}
+
+// Health: Logged in if any account is, blocked only if all are
+func (p *Panel) Health() provider.Health {
+	h := provider.Health{Name: p.Name(), Blocked: len(p.accounts) > 0}
+	for _, c := range p.accounts {
+		ah := c.Health()
+		ah.Account = c.Username
+		h.LoggedIn = h.LoggedIn || ah.LoggedIn
+		h.Blocked = h.Blocked && ah.Blocked
+		h.Accounts = append(h.Accounts, ah)
+	}
+	return h
+}
+
+func (p *Panel) GetSMSLogs() ([]byte, error) {
+	feed, err := p.PollSMS()
+	return feed.Legacy, err
+}
+
+func (p *Panel) FetchSMS() ([]provider.SMSRecord, error) {
+	feed, err := p.PollSMS()
+	return feed.Records, err
+}
+
+func (p *Panel) GetNumberStats() ([]byte, error) {
+	return p.merged((*Client).GetNumberStats)
+}
+
+// PollSMS: Both SMS views of every account from one upstream fetch each
+func (p *Panel) PollSMS() (provider.SMSFeed, error) {
+	return p.QuerySMS(provider.SMSQuery{})
+}
+
+// QuerySMS: Every account is asked for its first offset+limit rows, so the
+// merged list can be re-sorted and paged as if it were one panel
+func (p *Panel) QuerySMS(q provider.SMSQuery) (provider.SMSFeed, error) {
+	if len(p.accounts) == 1 {
+		return p.accounts[0].QuerySMS(q)
+	}
+
+	sub, offset, limit := q, 0, 0
+	if q.Offset > 0 || q.Limit > 0 {
+		offset, limit = q.Offset, q.Limit
+		if limit <= 0 {
+			limit = p.SMSPageSize
+		}
+		sub.Offset, sub.Limit = 0, 0
+		if limit > 0 {
+			sub.Limit = offset + limit
+		}
+	}
+
+	feeds := make(map[*Client]provider.SMSFeed)
+	var mu sync.Mutex
+	err := p.each(func(c *Client) error {
+		feed, err := c.QuerySMS(sub)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		feeds[c] = feed
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return provider.SMSFeed{}, err
+	}
+
+	// Accounts in config order, so SMS at the same second keep one order
+	legacy := make(map[*Client][]byte)
+	records := []provider.SMSRecord{}
+	for _, c := range p.accounts {
+		feed, ok := feeds[c]
+		if !ok {
+			continue
+		}
+		legacy[c] = feed.Legacy
+		records = append(records, feed.Records...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if q.Ascending() {
+			return records[i].Time.Before(records[j].Time)
+		}
+		return records[i].Time.After(records[j].Time)
+	})
+
+	order := "desc"
+	if q.Ascending() {
+		order = "asc"
+	}
+	combined, err := p.combine(legacy, order, offset, limit)
+	if err != nil {
+		return provider.SMSFeed{}, err
+	}
+	return provider.SMSFeed{Legacy: combined, Records: provider.Window(records, offset, limit)}, nil
+}
+
+// ---------------------------------------------------------
+// MERGED FEED
+// ---------------------------------------------------------
+
+// each: Runs fn for every account concurrently. Failures are logged; an
+// error is returned only if every account failed.
+func (p *Panel) each(fn func(*Client) error) error {
+	errs := make([]error, len(p.accounts))
+	var wg sync.WaitGroup
+	for i, c := range p.accounts {
+		wg.Add(1)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			errs[i] = fn(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var failures accountErrors
+	for i, c := range p.accounts {
+		if errs[i] != nil {
+			fmt.Printf("[%s] %s failed: %v\n", p.Tag, c.Username, errs[i])
+			failures = append(failures, accountError{c.Username, errs[i]})
+		}
+	}
+	if len(failures) == len(p.accounts) {
+		return failures
+	}
+	return nil
+}
+
+// merged: With one account the response is passed through, otherwise
+// every account is fetched and the results combined
+func (p *Panel) merged(fetch func(*Client) ([]byte, error)) ([]byte, error) {
+	if len(p.accounts) == 1 {
+		return fetch(p.accounts[0])
+	}
+
+	parts := make(map[*Client][]byte)
+	var mu sync.Mutex
+	err := p.each(func(c *Client) error {
+		body, err := fetch(c)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		parts[c] = body
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.combine(parts, "", 0, 0)
+}
+
+// combine: Joins per-account DataTables JSON; the account username is
+// appended as the last column of each row. Unparseable parts are skipped.
+// order "desc"/"asc" sorts by the date column, then offset/limit are applied.
+func (p *Panel) combine(parts map[*Client][]byte, order string, offset, limit int) ([]byte, error) {
+	out := ApiResponse{SEcho: "1", Provider: p.Name()}
+	for _, c := range p.accounts {
+		body, ok := parts[c]
+		if !ok {
+			continue
+		}
+		var part ApiResponse
+		if err := json.Unmarshal(body, &part); err != nil {
+			fmt.Printf("[%s] %s: bad JSON: %v\n", p.Tag, c.Username, err)
+			continue
+		}
+		for _, row := range part.AAData {
+			out.AAData = append(out.AAData, append(row, c.Username))
+		}
+	}
+
+	if order != "" {
+		// Date column is "2006-01-02 15:04:05"
+		sort.SliceStable(out.AAData, func(i, j int) bool {
+			a, _ := out.AAData[i][0].(string)
+			b, _ := out.AAData[j][0].(string)
+			if order == "asc" {
+				return a < b
+			}
+			return a > b
+		})
+	}
+	out.ITotalRecords = len(out.AAData)
+	out.ITotalDisplayRecords = len(out.AAData)
+	out.AAData = provider.Window(out.AAData, offset, limit)
+	return json.Marshal(out)
+}
+
+// FetchNumbers: Typed inventory of every account
+func (p *Panel) FetchNumbers() ([]provider.NumberRecord, error) {
+	parts := make(map[*Client][]provider.NumberRecord)
+	var mu sync.Mutex
+	err := p.each(func(c *Client) error {
+		recs, err := c.FetchNumbers()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		parts[c] = recs
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := []provider.NumberRecord{}
+	for _, c := range p.accounts {
+		records = append(records, parts[c]...)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Number < records[j].Number
+	})
+	return records, nil
+}
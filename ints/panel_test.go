@@ -0,0 +1,73 @@
+package ints
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"myproject/mockpanel"
+	"myproject/provider"
+)
+
+// twoAccounts: A panel with alice and bob, each on its own mock serving the
+// same rows, so every SMS and number ties between the accounts
+func twoAccounts(t *testing.T) *Panel {
+	t.Helper()
+	cfg := Config{
+		Name: "merge-order-test", Tag: "Merge", Path: "/ints", Role: "client",
+		Auth: TokenSessKey, ReportsPage: "SMSCDRStats",
+		SMSWindow: WindowToday, SMSPageSize: 100,
+		SMSLayout:    SMSLayout{Message: 4, Currency: 5, Cost: 6, Status: -1},
+		NumberLayout: ClientNumbers,
+	}
+	p := &Panel{Config: cfg}
+	now := time.Now()
+	for _, user := range []string{"alice", "bob"} {
+		srv := mockpanel.Start(mockpanel.Options{Panel: "d-group", Username: user, SMS: mockpanel.ClientSMS(now)})
+		t.Cleanup(srv.Close)
+		acc := cfg
+		acc.BaseURL, acc.Username, acc.Password, acc.Tag = srv.URL, user, "pass", cfg.Tag+"/"+user
+		p.accounts = append(p.accounts, New(acc))
+	}
+	return p
+}
+
+// Ties are broken by the account order of the config, on every call
+func TestMergeOrder(t *testing.T) {
+	p := twoAccounts(t)
+	accounts := func(n int, account func(i int) string) string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = account(i)
+		}
+		return strings.Join(out, ",")
+	}
+
+	var want string
+	for i := 0; i < 20; i++ {
+		feed, err := p.QuerySMS(provider.SMSQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := accounts(len(feed.Records), func(i int) string { return feed.Records[i].Account })
+		if i == 0 {
+			want = got
+			if !strings.HasPrefix(got, "alice,bob,") {
+				t.Fatalf("SMS accounts %s, want alice before bob on ties", got)
+			}
+		} else if got != want {
+			t.Fatalf("call %d: SMS accounts %s, first call %s", i, got, want)
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		recs, err := p.FetchNumbers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := accounts(len(recs), func(i int) string { return recs[i].Account })
+		if !strings.HasPrefix(got, "alice,bob,") {
+			t.Fatalf("call %d: number accounts %s, want alice before bob on ties", i, got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ints
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ---------------------------------------------------------
+// RECORD MODE (fixtures for the golden files, see golden_test.go)
+// ---------------------------------------------------------
+
+// With a record dir set, every raw panel response is saved as
+//
+//	<dir>/<panel>/login.html, reports.html, data_smscdr.json, data_smsnumbers.json
+//
+// (latest one wins) plus layout.json, with the session token, username and
+// password replaced so the files can be committed.
+
+var (
+	recordDir string
+	recordMu  sync.Mutex
+)
+
+// Record turns record mode on for all panels ("" = off).
+func Record(dir string) {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	recordDir = dir
+}
+
+// RecordedLayout: layout.json, lets the golden test read a capture without the
+// panel's definition
+type RecordedLayout struct {
+	SMS     SMSLayout    `json:"sms"`
+	Numbers NumberLayout `json:"numbers"`
+}
+
+var reTokenParam = regexp.MustCompile(`((?:sesskey|csstr)["']?\s*[=:]\s*["']?)[A-Za-z0-9%=]+`)
+
+// scrub: Credentials out of a body before it goes to disk
+func (c *Client) scrub(body []byte) []byte {
+	s := reTokenParam.ReplaceAllString(string(body), "${1}SCRUBBED")
+	var pairs []string
+	if c.Token != "" && c.Token != cookieMode {
+		pairs = append(pairs, c.Token, "SCRUBBED")
+	}
+	if len(c.Password) > 2 {
+		pairs = append(pairs, c.Password, "SCRUBBED_PASSWORD")
+	}
+	if len(c.Username) > 2 {
+		pairs = append(pairs, c.Username, "SCRUBBED_USER")
+	}
+	if len(pairs) > 0 {
+		s = strings.NewReplacer(pairs...).Replace(s)
+	}
+	return []byte(s)
+}
+
+// record: Saves one raw response when record mode is on
+func (c *Client) record(name string, body []byte) {
+	recordMu.Lock()
+	dir := recordDir
+	recordMu.Unlock()
+	if dir == "" {
+		return
+	}
+
+	dir = filepath.Join(dir, c.Name())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.logf("Record: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), c.scrub(body), 0o644); err != nil {
+		c.logf("Record: %v", err)
+		return
+	}
+	layout, _ := json.MarshalIndent(RecordedLayout{SMS: c.SMSLayout, Numbers: c.NumberLayout}, "", "  ")
+	os.WriteFile(filepath.Join(dir, "layout.json"), append(layout, '\n'), 0o644)
+}
@@ -0,0 +1,159 @@
+package ints
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/otp"
+	"myproject/provider"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// Panels print CDR dates in their own local time without a zone
+const panelTimeLayout = "2006-01-02 15:04:05"
+
+// parseSMS: Raw data_smscdr.php JSON -> typed records (v2 API).
+// Unlike cleanSMS, a body that is not JSON is an error, not passed through.
+func parseSMS(rawJSON []byte, l SMSLayout, name, account string) ([]provider.SMSRecord, error) {
+	var apiResp ApiResponse
+	if err := json.Unmarshal(rawJSON, &apiResp); err != nil {
+		return nil, fmt.Errorf("%s: %w: bad SMS JSON: %v", name, provider.ErrBadResponse, err)
+	}
+
+	records := []provider.SMSRecord{}
+	for _, row := range apiResp.AAData {
+		if len(row) <= l.Message {
+			continue
+		}
+		number := cleanNumber(cellText(row, 2))
+
+		rec := provider.SMSRecord{
+			Range:    cellText(row, 1),
+			Country:  regionCode(number),
+			Number:   number,
+			Service:  cellText(row, 3),
+			Message:  cleanMessage(cellString(row, l.Message)),
+			Currency: "$",
+			Status:   cellText(row, l.Status),
+			Provider: name,
+			Account:  account,
+		}
+		if t, err := time.ParseInLocation(panelTimeLayout, cellText(row, 0), provider.PanelLocation); err == nil {
+			rec.Time = t
+		}
+		if cur := cellText(row, l.Currency); cur != "" {
+			rec.Currency = cur
+		}
+		rec.Cost, _ = strconv.ParseFloat(strings.TrimSpace(cellText(row, l.Cost)), 64)
+		rec.OTP = otp.Extract(rec.Message, rec.Service)
+		rec.ID = provider.Fingerprint(rec)
+
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// cleanNumber: Strip spaces, dashes and a leading "+"
+func cleanNumber(number string) string {
+	number = strings.ReplaceAll(number, " ", "")
+	number = strings.ReplaceAll(number, "-", "")
+	return strings.TrimPrefix(number, "+")
+}
+
+var (
+	reTags      = regexp.MustCompile(`<[^>]*>`)
+	reStatPair  = regexp.MustCompile(`([A-Za-z][A-Za-z ]*?)\s*[:=]\s*\$?\s*(-?\d+(?:\.\d+)?)`)
+	reStatCount = regexp.MustCompile(`(-?\d+(?:\.\d+)?)\s+([A-Za-z][A-Za-z]*)`)
+)
+
+// parseNumbers: Raw data_smsnumbers.php JSON -> typed inventory (v2 API)
+func parseNumbers(rawJSON []byte, l NumberLayout, name, account string) ([]provider.NumberRecord, error) {
+	var apiResp ApiResponse
+	if err := json.Unmarshal(rawJSON, &apiResp); err != nil {
+		return nil, fmt.Errorf("%s: %w: bad numbers JSON: %v", name, provider.ErrBadResponse, err)
+	}
+
+	records := []provider.NumberRecord{}
+	for _, row := range apiResp.AAData {
+		if len(row) < l.columns() {
+			continue
+		}
+		digits := cleanNumber(cellText(row, l.Number))
+		if digits == "" {
+			continue
+		}
+
+		rec := provider.NumberRecord{
+			Number:   "+" + digits,
+			Range:    cellText(row, l.Range),
+			Provider: name,
+			Account:  account,
+		}
+
+		// Number, country and calling code via libphonenumber
+		if numObj, err := phonenumbers.Parse("+"+digits, ""); err == nil {
+			rec.Number = phonenumbers.Format(numObj, phonenumbers.E164)
+			rec.Country = phonenumbers.GetRegionCodeForNumber(numObj)
+			rec.CallingCode = int(numObj.GetCountryCode())
+		}
+
+		// Billing period + price
+		period, price := cellText(row, l.Period), cellText(row, l.Price)
+		if l.Period < 0 {
+			period, price = parsePriceHTML(price)
+		}
+		rec.Period = provider.ParsePeriod(period)
+		rec.Currency, rec.Price = parsePrice(price)
+
+		// Stats: "SMS: 12 | Cost: 0.24" or "12 SMS" style text
+		rec.StatsText = reTags.ReplaceAllString(cellText(row, l.Stats), " ")
+		rec.StatsText = strings.Join(strings.Fields(rec.StatsText), " ")
+		rec.Stats = parseStats(rec.StatsText)
+
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// parsePrice: "$ 0.01", "0.01", "€0.5" -> currency + value ("$" when absent)
+func parsePrice(s string) (string, float64) {
+	currency := "$"
+	if strings.Contains(s, "€") {
+		currency = "€"
+	} else if strings.Contains(s, "£") {
+		currency = "£"
+	}
+	matches := rePrice.FindAllString(s, -1)
+	if len(matches) == 0 {
+		return currency, 0
+	}
+	val, _ := strconv.ParseFloat(strings.Trim(matches[len(matches)-1], "."), 64)
+	return currency, val
+}
+
+// parseStats: Best effort "label: number" / "number label" pairs, keys lowercased
+func parseStats(s string) map[string]float64 {
+	stats := map[string]float64{}
+	for _, m := range reStatPair.FindAllStringSubmatch(s, -1) {
+		if v, err := strconv.ParseFloat(m[2], 64); err == nil {
+			stats[strings.ToLower(strings.TrimSpace(m[1]))] = v
+		}
+	}
+	if len(stats) > 0 {
+		return stats
+	}
+	for _, m := range reStatCount.FindAllStringSubmatch(s, -1) {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+			stats[strings.ToLower(m[2])] = v
+		}
+	}
+	if len(stats) == 0 {
+		return nil
+	}
+	return stats
+}
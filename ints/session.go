@@ -0,0 +1,86 @@
+package ints
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"myproject/session"
+)
+
+// ---------------------------------------------------------
+// SAVED SESSIONS (survive restarts, see package session)
+// ---------------------------------------------------------
+
+// UseSessions restores every account's saved session from st and keeps st
+// updated after each login. Restored sessions are not checked here; the
+// first fetch finds out, and an expired one just means a normal re-login.
+func (p *Panel) UseSessions(st *session.Store) {
+	for _, c := range p.accounts {
+		c.UseSessions(st)
+	}
+}
+
+func (c *Client) UseSessions(st *session.Store) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.sessions = st
+	sess, ok := st.Get(c.Name(), c.Username)
+	if !ok || sess.BaseURL != c.BaseURL {
+		return
+	}
+
+	c.Token = sess.Token
+	cookies := make([]*http.Cookie, len(sess.Cookies))
+	for i, ck := range sess.Cookies {
+		cookies[i] = &http.Cookie{Name: ck.Name, Value: ck.Value, Path: "/"}
+	}
+	c.HTTPClient.Jar.SetCookies(c.cookieURL(), cookies)
+
+	if c.hasSession() {
+		c.loggedIn.Store(true)
+		c.restored = true
+		c.logf("♻️ Session restored (obtained %s ago), checking on first use", time.Since(sess.ObtainedAt).Round(time.Second))
+	}
+}
+
+// cookieURL: Deepest path we call, so the jar returns cookies of every level
+func (c *Client) cookieURL() *url.URL {
+	u, _ := url.Parse(c.roleURL(""))
+	return u
+}
+
+// saveSession: After a successful login
+func (c *Client) saveSession() {
+	if c.sessions == nil {
+		return
+	}
+	sess := session.Session{
+		Panel:      c.Name(),
+		Account:    c.Username,
+		BaseURL:    c.BaseURL,
+		Token:      c.Token,
+		ObtainedAt: time.Now(),
+	}
+	for _, ck := range c.HTTPClient.Jar.Cookies(c.cookieURL()) {
+		sess.Cookies = append(sess.Cookies, session.Cookie{Name: ck.Name, Value: ck.Value})
+	}
+	if err := c.sessions.Put(sess); err != nil {
+		c.logf("Warning: could not save session: %v", err)
+	}
+}
+
+// forgetSession: The session was dropped (expired, blocked, forced re-login)
+func (c *Client) forgetSession() {
+	if c.restored {
+		c.logf("Restored session has expired")
+		c.restored = false
+	}
+	if c.sessions == nil {
+		return
+	}
+	if err := c.sessions.Delete(c.Name(), c.Username); err != nil {
+		c.logf("Warning: could not delete saved session: %v", err)
+	}
+}
@@ -1,90 +1,157 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"time"
+	_ "time/tzdata" // PANEL_TIMEZONE on images without zoneinfo (alpine)
 
-	"myproject/dgroup"
-	"myproject/mait"
-	"myproject/npmneon"
-	
+	"myproject/breaker"
+	"myproject/config"
+	"myproject/history"
+	"myproject/ints"
+	"myproject/poller"
+	"myproject/provider"
+	"myproject/session"
+	"myproject/stream"
+	"myproject/webhook"
+
+	// Panel definitions register themselves with ints from init()
+	_ "myproject/dgroup"
+	_ "myproject/mait"
+	_ "myproject/npmneon"
+	_ "myproject/numberpanel"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// =================================================================
+	// CONFIG: URLs اور لاگ ان اب config.json / env سے آتے ہیں (git میں نہیں)
+	// =================================================================
+	// Built-in panels can also be set up from PANEL_<NAME>_* alone (Docker)
+	cfg, err := config.Load(ints.Names()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Panel clocks are read in one fixed zone, whatever the server's TZ
+	provider.PanelLocation = cfg.PanelLocation
+	if cfg.RecordDir != "" {
+		log.Printf("[Record] saving raw panel responses to %s", cfg.RecordDir)
+		ints.Record(cfg.RecordDir)
+	}
+	registerPanels(cfg)
+
+	// ================= BACKGROUND POLLER =================
+	// پینل پر ہر ریکویسٹ نہیں جائے گی، پولر اسنیپ شاٹ رکھے گا
+	sched := poller.New(time.Duration(cfg.PollInterval))
+	for _, p := range cfg.Panels {
+		sched.SetInterval(p.Name, time.Duration(p.PollInterval))
+	}
+
+	// ================= WEBHOOKS (push new SMS) =================
+	hooks := webhook.New()
+	registerWebhooks(hooks, cfg.Webhooks)
+	sched.OnNew(hooks.Notify)
+
+	// ================= LIVE STREAMS =================
+	hub := stream.NewHub()
+	hub.AllowOrigins(cfg.WSOrigins...)
+	sched.OnNew(hub.Publish)
+
+	// ================= SMS HISTORY (bbolt) =================
+	// پینل صرف آج کے SMS دکھاتا ہے، پرانے یہاں محفوظ رہتے ہیں
+	// The store is also the ledger: a restart doesn't replay SMS already
+	// handed to webhooks/streams
+	store := openHistory(cfg)
+	if store != nil {
+		sched.SetLedger(store)
+		// Deliveries a previous run didn't finish go out again
+		if err := hooks.UseJournal(store); err != nil {
+			log.Printf("[Webhook] journal: %v", err)
+		}
+	}
+
+	sched.Start(context.Background())
+
 	r := gin.Default()
 
 	// =================================================================
-	// اہم تبدیلی: NewClient کی جگہ اب GetSession کال ہوگا
-	// یہ فنکشن اب RAM سے سیشن اٹھائے گا اور ہارڈ کوڈڈ لاگ ان استعمال کرے گا
+	// PROVIDER ROUTES: /<name>/sms and /<name>/numbers for every panel
+	// نیا پینل شامل کرنے کے لیے صرف ایک پیکج لکھیں اور اوپر import کریں
 	// =================================================================
-	
-	dClient := dgroup.GetSession()
-	
-	neonClient := npmneon.GetSession()
-	maitClient := mait.GetSession()
-
-	// ================= D-GROUP ROUTES =================
-	r.GET("/d-group/sms", func(c *gin.Context) {
-		data, err := dClient.GetSMSLogs()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	for _, p := range provider.All() {
+		mountProvider(r, p, sched)
+	}
+	// GET /sms, GET /numbers: every provider merged, partial failures in "errors"
+	mountAggregate(r, sched)
+	// Old per-account URLs (/number-panel-1/...) for accounts with an alias
+	mountAliases(r, cfg)
+
+	// ================= OTP WAIT (Long-poll) =================
+	r.GET("/otp/wait", func(c *gin.Context) {
+		start := time.Now()
+		number := c.Query("number")
+		if number == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "number is required", "code": "bad_request"})
 			return
 		}
-		c.Data(http.StatusOK, "application/json", data)
-	})
-
-	r.GET("/d-group/numbers", func(c *gin.Context) {
-		data, err := dClient.GetNumberStats()
+		timeout, err := parseTimeout(c.DefaultQuery("timeout", "120s"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "bad_request"})
 			return
 		}
-		c.Data(http.StatusOK, "application/json", data)
-	})
 
-	// ================= NUMBER PANEL ROUTES =================
-	
-	// ================= NPM-NEON ROUTES =================
-	r.GET("/npm-neon/sms", func(c *gin.Context) {
-		data, err := neonClient.GetSMSLogs()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		rec, err := sched.Wait(ctx, poller.Filter{Number: number, Service: c.Query("service"), Since: start})
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusRequestTimeout, gin.H{"error": "no SMS for " + number + " within " + timeout.String(), "code": "timeout"})
 			return
 		}
-		c.Data(http.StatusOK, "application/json", data)
-	})
-
-	r.GET("/npm-neon/numbers", func(c *gin.Context) {
-		data, err := neonClient.GetNumberStats()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return // Client went away
 		}
-		c.Data(http.StatusOK, "application/json", data)
-	})
 
-	// ================= MAIT (Masdar) ROUTES =================
-	r.GET("/mait/sms", func(c *gin.Context) {
-		data, err := maitClient.GetSMSLogs()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		code := ""
+		if rec.OTP != nil {
+			code = rec.OTP.Code
 		}
-		c.Data(http.StatusOK, "application/json", data)
+		c.JSON(http.StatusOK, gin.H{"number": rec.Number, "code": code, "otp": rec.OTP, "record": rec})
 	})
 
-	r.GET("/mait/numbers", func(c *gin.Context) {
-		data, err := maitClient.GetNumberStats()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	r.GET("/health", func(c *gin.Context) {
+		var out []provider.Health
+		for _, p := range provider.All() {
+			out = append(out, p.Health())
 		}
-		c.Data(http.StatusOK, "application/json", data)
+		c.JSON(http.StatusOK, out)
+	})
+
+	r.GET("/poller/status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, sched.Status())
 	})
 
+	// Circuit breaker per panel: closed / open / half_open, retry_after in seconds
+	r.GET("/breakers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, breaker.All())
+	})
+
+	// Server-Sent Events: every new SMS once, resumable via Last-Event-ID
+	r.GET("/stream/sms", hub.ServeSSE)
+	// WebSocket: same feed, client picks numbers/ranges/services to follow
+	r.GET("/ws", hub.ServeWS)
+
+	if store != nil {
+		r.GET("/history/sms", store.ServeSMS)
+	}
+
+	mountAdmin(r, hooks)
+
 	// ================= SERVER START =================
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -93,3 +160,67 @@ func main() {
 	log.Println("Server running on port: " + port)
 	r.Run("0.0.0.0:" + port)
 }
+
+// registerPanels: Builds a driver for every enabled config entry
+func registerPanels(cfg *config.Config) {
+	sessions := openSessions(cfg)
+	for _, p := range cfg.Panels {
+		if !p.IsEnabled() {
+			log.Printf("[Config] %s disabled, skipping", p.Name)
+			continue
+		}
+		client, err := ints.Build(p)
+		if err != nil {
+			log.Printf("[Config] %v", err)
+			continue
+		}
+		if sessions != nil {
+			client.UseSessions(sessions)
+		}
+		provider.Register(client)
+	}
+	if len(provider.All()) == 0 {
+		log.Println("[Config] Warning: no panels enabled")
+	}
+}
+
+// openHistory: nil when disabled ("off") or the file can't be opened
+func openHistory(cfg *config.Config) *history.Store {
+	path := cfg.HistoryDB
+	if path == "off" {
+		log.Println("[History] disabled")
+		return nil
+	}
+	if path == "" {
+		path = history.DefaultPath
+	}
+	store, err := history.Open(path)
+	if err != nil {
+		log.Printf("[History] %v, running without history", err)
+		return nil
+	}
+	log.Printf("[History] %s (%d records)", path, store.Count())
+	return store
+}
+
+// openSessions: Saved panel logins, so a restart doesn't re-solve every captcha.
+// nil (RAM only) without SESSION_KEY.
+func openSessions(cfg *config.Config) *session.Store {
+	if cfg.SessionKey == "" {
+		log.Println("[Session] SESSION_KEY not set, sessions are not saved across restarts")
+		return nil
+	}
+	if len(cfg.SessionKey) < 32 {
+		log.Println("[Session] SESSION_KEY is short; use a random value such as `openssl rand -hex 32`")
+	}
+	path := cfg.SessionFile
+	if path == "" {
+		path = session.DefaultPath
+	}
+	store, err := session.Open(path, cfg.SessionKey)
+	if err != nil {
+		log.Printf("[Session] %v", err)
+		return nil
+	}
+	return store
+}
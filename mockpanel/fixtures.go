@@ -0,0 +1,83 @@
+package mockpanel
+
+import "time"
+
+// Default fixtures, shaped like the real panels (see ints.SMSLayout and
+// ints.NumberLayout). SMS dates are relative to now so a "today" window
+// always sees them.
+
+const dateLayout = "2006-01-02 15:04:05"
+
+// ClientSMS: d-group style [Date, Range, Number, Sender, Message, Currency, Cost]
+func ClientSMS(now time.Time) [][]any {
+	at := func(m int) string { return now.Add(-time.Duration(m) * time.Minute).Format(dateLayout) }
+	return [][]any{
+		{at(1), "Algeria Mobilis TF04", "213551234567", "WhatsApp", "Your WhatsApp code 482-913\nnull", "$", "0.01"},
+		{at(5), "Egypt Vodafone 2", "201001234567", "Telegram", "Telegram code: 55123. Do not give this code to anyone", "$", "0.005"},
+		{at(9), "Algeria Mobilis TF04", "213557654321", "Google", "&lt;#&gt; G-731094 is your Google verification code.", "$", 0.01},
+		{at(30), "Kenya Safaricom", "254712345678", "INFO", "Welcome to the network", "$", "0"},
+	}
+}
+
+// AgentSMS: mait style [Date, Range, Number, Service, User, Message, Currency, Cost, Status]
+func AgentSMS(now time.Time) [][]any {
+	at := func(m int) string { return now.Add(-time.Duration(m) * time.Minute).Format(dateLayout) }
+	return [][]any{
+		{at(2), "Algeria Mobilis TF04", "213551234567", "WhatsApp", "client01", "Your WhatsApp code 482-913", "USD", "0.01", "Paid"},
+		{at(7), "Pakistan Jazz", "923001234567", "Facebook", "client02", "FB-28461 is your Facebook confirmation code", "USD", "0.008", "Paid"},
+		{at(15), "Egypt Vodafone 2", "201001234567", "TikTok", "client01", "[TikTok] 604271 is your verification code", "USD", "0", "Unpaid"},
+	}
+}
+
+// NeonSMS: npm-neon style [Date, Country, Number, Service, User, Message, Cost, Status]
+func NeonSMS(now time.Time) [][]any {
+	at := func(m int) string { return now.Add(-time.Duration(m) * time.Minute).Format(dateLayout) }
+	return [][]any{
+		{at(3), "Algeria", "213551234567", "WhatsApp", "client01", "Your WhatsApp code 482-913", "0.01", "Paid"},
+		{at(12), "Pakistan", "923001234567", "Telegram", "client02", "Telegram code: 55123", "0.005", "Unpaid"},
+	}
+}
+
+// NumberPanelSMS: number-panel style [Date, Range, Number, Sender, Message, Cost]
+func NumberPanelSMS(now time.Time) [][]any {
+	at := func(m int) string { return now.Add(-time.Duration(m) * time.Minute).Format(dateLayout) }
+	return [][]any{
+		{at(4), "Egypt Vodafone 2", "201001234567", "Google", "G-731094 is your Google verification code.", "0.02"},
+		{at(20), "Algeria Mobilis TF04", "213557654321", "WhatsApp", "Your WhatsApp code 119-204", 0.01},
+	}
+}
+
+// PanelSMS: SMS fixture in the column layout of a built-in panel; other
+// names get ClientSMS / AgentSMS by role
+func PanelSMS(panel, role string, now time.Time) [][]any {
+	switch panel {
+	case "mait":
+		return AgentSMS(now)
+	case "npm-neon":
+		return NeonSMS(now)
+	case "number-panel":
+		return NumberPanelSMS(now)
+	case "d-group":
+		return ClientSMS(now)
+	}
+	if role == "agent" {
+		return AgentSMS(now)
+	}
+	return ClientSMS(now)
+}
+
+// ClientNumbers: [Range, Prefix(empty), Number, Period, Price, Stats]
+func ClientNumbers() [][]any {
+	return [][]any{
+		{"Algeria Mobilis TF04", "", "213 551 234567", "Weekly", "$ 0.50", "<b>SMS:</b> 12 <b>Paid:</b> $0.12"},
+		{"Egypt Vodafone 2", "", "20-100-1234567", "Monthly", "$ 1.20", "<b>SMS:</b> 3 <b>Paid:</b> $0.015"},
+	}
+}
+
+// AgentNumbers: [Checkbox, Range, Prefix, Number, PriceHTML, Action, Empty, Stats]
+func AgentNumbers() [][]any {
+	return [][]any{
+		{"<input type='checkbox' value='1'>", "Algeria Mobilis TF04", "213", "213551234567", "<b>$ 0.50</b> Weekly", "<a href='#'>Remove</a>", "", "<b>12</b> SMS"},
+		{"<input type='checkbox' value='2'>", "Pakistan Jazz", "92", "923001234567", "<b>€ 0.80</b> Monthly", "<a href='#'>Remove</a>", "", "<b>4</b> SMS"},
+	}
+}
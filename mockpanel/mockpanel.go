@@ -0,0 +1,410 @@
+package mockpanel
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myproject/provider"
+)
+
+// Fake IMS panel for offline testing. It speaks just enough of the real
+// thing for the ints driver: login page with a math captcha, signin with a
+// PHPSESSID cookie, reports page carrying sesskey/csstr, and the two
+// res/data_*.php DataTables endpoints served from fixtures.
+//
+// Failure modes can be switched on at runtime: ExpireSessions (next AJAX call
+// gets the login page), FailNext (HTML error page), SetBlocked (403 Forbidden).
+//
+//	srv := mockpanel.Start(mockpanel.Options{Panel: "mait", Role: "agent", Auth: "csstr"})
+//	defer srv.Close()
+//	// point a panel's base_url at srv.URL
+
+const sessionCookie = "PHPSESSID"
+
+type Options struct {
+	Path        string // Default "/ints"
+	Role        string // "client" (default) or "agent"
+	ReportsPage string // Default SMSCDRStats (client) / SMSCDRReports (agent)
+	Auth        string // "sesskey" (default), "csstr" or "cookie"
+	Username    string // Default "user"
+	Password    string // Default "pass"
+
+	Panel   string  // Built-in panel whose SMS column layout the default rows use (see PanelSMS)
+	SMS     [][]any // data_smscdr.php rows, default PanelSMS(Panel, Role)
+	Numbers [][]any // data_smsnumbers.php rows, default ClientNumbers / AgentNumbers
+
+	SessionTTL time.Duration // > 0: sessions expire on their own
+	Ignore     []string      // Search params dropped unread, for drivers with ints.Config.LocalFilters
+}
+
+// Stats: What the panel saw, for assertions
+type Stats struct {
+	LoginPages   int `json:"login_pages"`
+	Logins       int `json:"logins"`        // Successful signins
+	FailedLogins int `json:"failed_logins"` // Wrong password or captcha
+	DataRequests int `json:"data_requests"`
+	Rejected     int `json:"rejected"` // Data requests without a valid session
+}
+
+type session struct {
+	answer  string
+	authed  bool
+	token   string
+	created time.Time
+}
+
+// Panel is the http.Handler; Start wraps it in an httptest.Server.
+type Panel struct {
+	opts Options
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	sms      [][]any
+	numbers  [][]any
+	blocked  bool
+	failNext int
+	stats    Stats
+	captchaN int
+}
+
+func New(opts Options) *Panel {
+	if opts.Path == "" {
+		opts.Path = "/ints"
+	}
+	if opts.Role == "" {
+		opts.Role = "client"
+	}
+	if opts.ReportsPage == "" {
+		opts.ReportsPage = "SMSCDRStats"
+		if opts.Role == "agent" {
+			opts.ReportsPage = "SMSCDRReports"
+		}
+	}
+	if opts.Auth == "" {
+		opts.Auth = "sesskey"
+	}
+	if opts.Username == "" {
+		opts.Username = "user"
+	}
+	if opts.Password == "" {
+		opts.Password = "pass"
+	}
+	if opts.SMS == nil {
+		opts.SMS = PanelSMS(opts.Panel, opts.Role, time.Now().In(provider.PanelLocation))
+	}
+	if opts.Numbers == nil {
+		opts.Numbers = ClientNumbers()
+		if opts.Role == "agent" {
+			opts.Numbers = AgentNumbers()
+		}
+	}
+	return &Panel{opts: opts, sessions: map[string]*session{}, sms: opts.SMS, numbers: opts.Numbers}
+}
+
+// Server: A running fake panel
+type Server struct {
+	*httptest.Server
+	*Panel
+}
+
+// Start serves a new Panel on a random local port.
+func Start(opts Options) *Server {
+	p := New(opts)
+	return &Server{Server: httptest.NewServer(p), Panel: p}
+}
+
+// ---------------------- CONTROLS ----------------------
+
+// ExpireSessions logs everyone out; the next AJAX call gets the login page.
+func (p *Panel) ExpireSessions() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions = map[string]*session{}
+}
+
+// SetBlocked: While on, every request gets 403 Forbidden (mait's IP ban).
+func (p *Panel) SetBlocked(on bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocked = on
+}
+
+// FailNext makes the next n data requests return an HTML error page.
+func (p *Panel) FailNext(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failNext = n
+}
+
+func (p *Panel) SetSMS(rows [][]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sms = rows
+}
+
+// AddSMS appends one CDR row (a new message arriving).
+func (p *Panel) AddSMS(row []any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sms = append(p.sms, row)
+}
+
+func (p *Panel) SetNumbers(rows [][]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.numbers = rows
+}
+
+func (p *Panel) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+// ---------------------- HTTP ----------------------
+
+func (p *Panel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.blocked {
+		writeHTML(w, http.StatusForbidden, "<h1>403 Forbidden</h1><p>You don't have permission to access this resource.</p>")
+		return
+	}
+
+	role := p.opts.Path + "/" + p.opts.Role + "/"
+	switch path := r.URL.Path; {
+	case path == p.opts.Path+"/login":
+		p.loginPage(w, r)
+	case path == p.opts.Path+"/signin" && r.Method == http.MethodPost:
+		p.signin(w, r)
+	case path == role+p.opts.ReportsPage:
+		p.reportsPage(w, r)
+	case path == role+"res/data_smscdr.php":
+		p.data(w, r, p.sms, true)
+	case path == role+"res/data_smsnumbers.php":
+		p.data(w, r, p.numbers, false)
+	case strings.HasPrefix(path, role):
+		if p.session(r) == nil {
+			http.Redirect(w, r, p.opts.Path+"/login", http.StatusFound)
+			return
+		}
+		writeHTML(w, http.StatusOK, "<h1>Dashboard</h1>")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// session: The logged-in session of r, nil if none (or expired)
+func (p *Panel) session(r *http.Request) *session {
+	ck, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	s := p.sessions[ck.Value]
+	if s == nil || !s.authed {
+		return nil
+	}
+	if p.opts.SessionTTL > 0 && time.Since(s.created) > p.opts.SessionTTL {
+		delete(p.sessions, ck.Value)
+		return nil
+	}
+	return s
+}
+
+func (p *Panel) loginPage(w http.ResponseWriter, r *http.Request) {
+	p.stats.LoginPages++
+
+	id := randomHex(16)
+	if ck, err := r.Cookie(sessionCookie); err == nil && p.sessions[ck.Value] != nil {
+		id = ck.Value
+	} else {
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: id, Path: "/", HttpOnly: true})
+	}
+
+	// Cycle through the operators so every login exercises the solver
+	p.captchaN++
+	a, b := 2+p.captchaN%9, 1+p.captchaN%5
+	question, answer := "", ""
+	switch p.captchaN % 3 {
+	case 0:
+		question, answer = fmt.Sprintf("%d + %d", a, b), strconv.Itoa(a+b)
+	case 1:
+		question, answer = fmt.Sprintf("%d - %d", a, b), strconv.Itoa(a-b)
+	default:
+		question, answer = fmt.Sprintf("%d * %d", a, b), strconv.Itoa(a*b)
+	}
+	p.sessions[id] = &session{answer: answer, created: time.Now()}
+
+	writeHTML(w, http.StatusOK, `<form action="signin" method="post">
+<input type="text" name="username"><input type="password" name="password">
+<label>What is `+question+` = ? :</label><input type="number" name="capt">
+<button type="submit">Sign In</button></form>`)
+}
+
+func (p *Panel) signin(w http.ResponseWriter, r *http.Request) {
+	var s *session
+	if ck, err := r.Cookie(sessionCookie); err == nil {
+		s = p.sessions[ck.Value]
+	}
+	if s == nil || r.FormValue("capt") != s.answer ||
+		r.FormValue("username") != p.opts.Username || r.FormValue("password") != p.opts.Password {
+		p.stats.FailedLogins++
+		http.Redirect(w, r, p.opts.Path+"/login", http.StatusFound)
+		return
+	}
+
+	p.stats.Logins++
+	s.authed, s.token, s.created = true, randomHex(8), time.Now()
+	http.Redirect(w, r, p.opts.Path+"/"+p.opts.Role+"/SMSDashboard", http.StatusFound)
+}
+
+func (p *Panel) reportsPage(w http.ResponseWriter, r *http.Request) {
+	s := p.session(r)
+	if s == nil {
+		http.Redirect(w, r, p.opts.Path+"/login", http.StatusFound)
+		return
+	}
+	script := ""
+	switch p.opts.Auth {
+	case "sesskey":
+		script = `"sAjaxSource": "res/data_smscdr.php?sesskey=` + s.token + `",`
+	case "csstr":
+		script = `"sAjaxSource": "res/data_smscdr.php?csstr=` + s.token + `",`
+	}
+	writeHTML(w, http.StatusOK, "<h1>SMS Reports</h1><table id=\"dt\"></table><script>$('#dt').dataTable({"+script+"});</script>")
+}
+
+func (p *Panel) data(w http.ResponseWriter, r *http.Request, rows [][]any, cdr bool) {
+	p.stats.DataRequests++
+	q := r.URL.Query()
+
+	s := p.session(r)
+	if s != nil {
+		switch p.opts.Auth {
+		case "sesskey":
+			if q.Get("sesskey") != s.token {
+				s = nil
+			}
+		case "csstr":
+			if q.Get("csstr") != s.token {
+				s = nil
+			}
+		}
+	}
+	if s == nil {
+		// Real panels bounce expired AJAX calls to the login page
+		p.stats.Rejected++
+		http.Redirect(w, r, p.opts.Path+"/login", http.StatusFound)
+		return
+	}
+	if p.failNext > 0 {
+		p.failNext--
+		writeHTML(w, http.StatusInternalServerError, "<h1>500 Internal Server Error</h1><p>mysqli_connect(): Too many connections</p>")
+		return
+	}
+
+	for _, k := range p.opts.Ignore {
+		q.Del(k)
+	}
+	rows = query(rows, q, cdr)
+	total := len(rows)
+	start, _ := strconv.Atoi(q.Get("iDisplayStart"))
+	length, err := strconv.Atoi(q.Get("iDisplayLength"))
+	if err != nil || length < 0 {
+		length = total
+	}
+	if start > total {
+		start = total
+	}
+	end := start + length
+	if end > total {
+		end = total
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sEcho":                q.Get("sEcho"),
+		"iTotalRecords":        total,
+		"iTotalDisplayRecords": total,
+		"aaData":               rows[start:end],
+	})
+}
+
+// query: The DataTables filters the panel honours: sSearch on any cell, and
+// for CDRs fdate1/fdate2 on the date, frange/fnum/fcli on range/number/sender
+// and sSortDir_0 on the date
+func query(rows [][]any, q map[string][]string, cdr bool) [][]any {
+	get := func(k string) string {
+		if v := q[k]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	contains := func(row []any, col int, want string) bool {
+		return want == "" || (col < len(row) && strings.Contains(strings.ToLower(fmt.Sprint(row[col])), strings.ToLower(want)))
+	}
+
+	from, to, search := get("fdate1"), get("fdate2"), get("sSearch")
+	out := [][]any{}
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		if cdr {
+			date := fmt.Sprint(row[0])
+			if (from != "" && date < from) || (to != "" && date > to) {
+				continue
+			}
+			if !contains(row, 1, get("frange")) || !contains(row, 2, get("fnum")) || !contains(row, 3, get("fcli")) {
+				continue
+			}
+		}
+		if search != "" {
+			found := false
+			for col := range row {
+				if contains(row, col, search) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		out = append(out, row)
+	}
+
+	if cdr {
+		desc := get("sSortDir_0") == "desc"
+		sort.SliceStable(out, func(i, j int) bool {
+			a, b := fmt.Sprint(out[i][0]), fmt.Sprint(out[j][0])
+			if desc {
+				return a > b
+			}
+			return a < b
+		})
+	}
+	return out
+}
+
+func writeHTML(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, "<!DOCTYPE html>\n<html><head><title>SMS Panel</title></head><body>"+body+"</body></html>\n")
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
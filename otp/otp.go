@@ -0,0 +1,211 @@
+package otp
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Result: Verification code found in an SMS body
+type Result struct {
+	Code       string  `json:"code"`              // Digits / characters only, e.g. "123456"
+	Raw        string  `json:"raw"`               // As written, e.g. "123-456"
+	Service    string  `json:"service,omitempty"` // e.g. "WhatsApp"
+	Confidence float64 `json:"confidence"`        // 0..1
+}
+
+var (
+	// Keyword followed (within a few words) by the code. \b only knows ASCII
+	// letters, so the Arabic / Cyrillic keywords go without it.
+	reKeyword = regexp.MustCompile(`(?i:\b(?:code|otp|pin|passcode|password|verification|verify|token)\b|كود|رمز|код)[^0-9]{0,25}?\b(\d{2,4}[- ]\d{2,4}|(?i:[a-z]{0,6}\d[a-z0-9]{0,7}))\b`)
+	// "123456 is your ..." (code first, Facebook / Google style)
+	reIsYour = regexp.MustCompile(`\b(\d{2,4}[- ]\d{2,4}|\d{4,8})\s+(?i:is\s+your)\b`)
+	// "123-456" / "123 456" / "12-34-56"
+	reGrouped = regexp.MustCompile(`\b(\d{2,4}(?:[- ]\d{2,4}){1,2})\b`)
+	// Plain 4-8 digit run
+	reDigits = regexp.MustCompile(`\b\d{4,8}\b`)
+	// Upper-case/digit mix, e.g. "G-123456" handled by grouped, "AB12CD" here
+	reAlnum = regexp.MustCompile(`\b[A-Z0-9]{4,8}\b`)
+	// Context that makes a number an amount or part of a phone number:
+	// currency before it, unit after it, "+" or more digits glued on
+	reAmountBefore = regexp.MustCompile(`(?i)(?:[$€£¥₹+]|\b(?:usd|eur|gbp|rs|pkr|inr|dzd|egp|ngn|sar|aed)|\d[.,]?)\s*[-]?$`)
+	reAmountAfter  = regexp.MustCompile(`(?i)^(?:\s*[$€£¥₹%]|[.,]\d|[ -]?\d|\s*(?:usd|eur|gbp|rs|pkr|inr|dzd|egp|ngn|sar|aed|points?|pts|coins?|dollars?|mb|gb|mins?|minutes?|hours?|days?)\b)`)
+	// "[Service]" or "Service:" at the start of the message
+	reLeadName = regexp.MustCompile(`^\s*(?:<#>\s*)?\[?([A-Z][A-Za-z0-9]{1,19})\]?\s*[:\-]`)
+)
+
+// Known senders, matched case-insensitively anywhere in the message
+var services = []string{
+	"WhatsApp", "Telegram", "Facebook", "Instagram", "Messenger", "Google", "Gmail",
+	"Microsoft", "Apple", "TikTok", "Twitter", "Snapchat", "Amazon", "Uber", "Viber",
+	"Signal", "Discord", "LINE", "WeChat", "imo", "PayPal", "Binance", "Tinder",
+	"Bolt", "Careem", "Yahoo", "Netflix", "Shopee", "Alibaba", "Payoneer", "Steam",
+	"LinkedIn", "Bumble", "Badoo", "Hinge", "Grab", "Gojek", "Airbnb", "Coinbase",
+}
+
+// Below this the "code" is most likely a year, amount or phone fragment
+const minConfidence = 0.4
+
+type candidate struct {
+	raw   string
+	score float64
+}
+
+// Extract finds the most likely verification code in message.
+// sender is the panel's CLI column and is used as a service fallback.
+// Returns nil when nothing looks like a code.
+func Extract(message, sender string) *Result {
+	if strings.TrimSpace(message) == "" {
+		return nil
+	}
+
+	var cands []candidate
+	for _, m := range reKeyword.FindAllStringSubmatch(message, -1) {
+		if n := len(normalize(m[1])); n >= 4 && n <= 8 {
+			cands = append(cands, candidate{m[1], 0.95})
+		}
+	}
+	for _, m := range reIsYour.FindAllStringSubmatch(message, -1) {
+		cands = append(cands, candidate{m[1], 0.9})
+	}
+	// Without a keyword, amounts and phone numbers are not codes
+	for _, loc := range reGrouped.FindAllStringIndex(message, -1) {
+		m := message[loc[0]:loc[1]]
+		if n := len(digitsOnly(m)); n >= 4 && n <= 8 && !amountOrPhone(message, loc) {
+			cands = append(cands, candidate{m, 0.8})
+		}
+	}
+	for _, loc := range reDigits.FindAllStringIndex(message, -1) {
+		m := message[loc[0]:loc[1]]
+		if amountOrPhone(message, loc) {
+			continue
+		}
+		score := 0.7
+		if looksLikeYear(m) {
+			score = 0.3
+		}
+		cands = append(cands, candidate{m, score})
+	}
+	for _, loc := range reAlnum.FindAllStringIndex(message, -1) {
+		m := message[loc[0]:loc[1]]
+		if hasDigit(m) && hasLetter(m) && !amountOrPhone(message, loc) {
+			cands = append(cands, candidate{m, 0.5})
+		}
+	}
+	if len(cands) == 0 {
+		return nil
+	}
+
+	// Highest score wins; on a tie the earliest match (stable sort) wins
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].score > cands[j].score })
+	best := cands[0]
+
+	// Several different plain numbers and no keyword: less sure which one it is
+	if best.score < 0.9 && distinctCodes(cands) > 1 {
+		best.score -= 0.2
+	}
+	if best.score < minConfidence {
+		return nil
+	}
+
+	return &Result{
+		Code:       normalize(best.raw),
+		Raw:        best.raw,
+		Service:    Service(message, sender),
+		Confidence: math.Round(best.score*100) / 100,
+	}
+}
+
+// Service guesses who sent the code: known brand in the text, then an
+// alphabetic sender ID, then a "[Name]" / "Name:" prefix.
+func Service(message, sender string) string {
+	lower := strings.ToLower(message)
+	for _, s := range services {
+		if containsWord(lower, strings.ToLower(s)) {
+			return s
+		}
+	}
+	sender = strings.TrimSpace(sender)
+	if sender != "" && hasLetter(sender) && !hasDigitOnly(sender) {
+		return sender
+	}
+	if m := reLeadName.FindStringSubmatch(message); len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// ---------------------- HELPERS ----------------------
+
+func normalize(raw string) string {
+	var b strings.Builder
+	for _, r := range raw {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+func hasLetter(s string) bool {
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+func hasDigitOnly(s string) bool {
+	return strings.TrimLeft(s, "+0123456789") == ""
+}
+
+// amountOrPhone: The match at loc reads as money, a quantity or a piece of
+// a longer number ("$ 1500", "50000 points", "1,500", "+213 5512 3456")
+func amountOrPhone(message string, loc []int) bool {
+	return reAmountBefore.MatchString(message[:loc[0]]) || reAmountAfter.MatchString(message[loc[1]:])
+}
+
+func looksLikeYear(s string) bool {
+	return len(s) == 4 && (strings.HasPrefix(s, "19") || strings.HasPrefix(s, "20"))
+}
+
+func distinctCodes(cands []candidate) int {
+	seen := map[string]bool{}
+	for _, c := range cands {
+		seen[normalize(c.raw)] = true
+	}
+	return len(seen)
+}
+
+// containsWord: needle in haystack not glued to other letters ("line" != "online")
+func containsWord(haystack, needle string) bool {
+	for i := 0; ; {
+		j := strings.Index(haystack[i:], needle)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(needle)
+		before := start == 0 || !isWordByte(haystack[start-1])
+		after := end == len(haystack) || !isWordByte(haystack[end])
+		if before && after {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
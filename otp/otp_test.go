@@ -0,0 +1,80 @@
+package otp
+
+import "testing"
+
+func TestExtract(t *testing.T) {
+	for _, tc := range []struct {
+		message, sender string
+		code, service   string // code "" = no result
+	}{
+		// Numeric
+		{"Your WhatsApp code 482913", "", "482913", "WhatsApp"},
+		{"Telegram code: 55123. Do not give this code to anyone", "", "55123", "Telegram"},
+		{"Your OTP is 8841", "BANK", "8841", "BANK"},
+		{"Use PIN 0042 for your card", "", "0042", ""},
+		{"7390 is your verification code", "", "7390", ""},
+		{"كود التحقق الخاص بك 582014", "", "582014", ""},
+		{"Ваш код 4417", "", "4417", ""},
+
+		// Alphanumeric, any case
+		{"Your verification code is abc123", "", "ABC123", ""},
+		{"Your verification code is AB12CD", "", "AB12CD", ""},
+
+		// Prefixed and split
+		{"G-731094 is your Google verification code.", "", "731094", "Google"},
+		{"Your WhatsApp code 482-913\nnull", "", "482913", "WhatsApp"},
+		{"123 456 is your Instagram code. Don't share it.", "", "123456", "Instagram"},
+		{"[TikTok] 604271 is your verification code", "", "604271", "TikTok"},
+
+		// Not codes
+		{"Shopping reward: 50000 points", "", "", ""},
+		{"Your balance is $ 1500", "", "", ""},
+		{"You paid 2500 DZD at the store", "", "", ""},
+		{"Recharge of 1,500.00 successful", "", "", ""},
+		{"Happy new year 2025!", "", "", ""},
+		{"Call us on +213 5512 3456 for help", "", "", ""},
+		{"Call 0551 23 45 67 for help", "", "", ""},
+		{"Get 2000 MB free this weekend", "", "", ""},
+		{"Welcome to the network", "", "", ""},
+		{"", "", "", ""},
+	} {
+		got := Extract(tc.message, tc.sender)
+		if tc.code == "" {
+			if got != nil {
+				t.Errorf("%q: got %+v, want no code", tc.message, got)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("%q: no code, want %s", tc.message, tc.code)
+			continue
+		}
+		if got.Code != tc.code || got.Service != tc.service {
+			t.Errorf("%q: got code %q service %q, want %q %q", tc.message, got.Code, got.Service, tc.code, tc.service)
+		}
+	}
+}
+
+// A keyword inside another word is not a keyword ("pin" in "Shopping")
+func TestKeywordWholeWord(t *testing.T) {
+	for _, msg := range []string{"Shopping 77881", "Spinning class 4455 tonight"} {
+		if got := Extract(msg, ""); got != nil && got.Confidence >= 0.9 {
+			t.Errorf("%q: confidence %v, want no keyword match", msg, got.Confidence)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	for _, tc := range []struct{ message, sender, want string }{
+		{"Your WhatsApp code 1234", "", "WhatsApp"},
+		{"You are online now 1234", "", ""}, // "line" inside "online"
+		{"Code 1234", "Jazz", "Jazz"},
+		{"Code 1234", "+923001234567", ""},
+		{"Careem: 1234 is your code", "", "Careem"},
+		{"Acme: 1234 is your code", "", "Acme"},
+	} {
+		if got := Service(tc.message, tc.sender); got != tc.want {
+			t.Errorf("Service(%q, %q) = %q, want %q", tc.message, tc.sender, got, tc.want)
+		}
+	}
+}
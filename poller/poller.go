@@ -0,0 +1,376 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"myproject/provider"
+)
+
+// Background SMS scheduler. Every provider is polled on its own interval,
+// new messages are found by their ID (through the Ledger when one is set, else
+// by diffing against the previous poll), and
+// HTTP reads are answered from the in-memory snapshot instead of the panel.
+
+const DefaultInterval = 10 * time.Second
+
+// Filter: What a waiter is waiting for (empty fields match anything)
+type Filter struct {
+	Number  string    // Digits, with or without country code
+	Service string    // Case-insensitive, matched against sender, OTP service and message
+	Since   time.Time // Zero: any time. Else only SMS received from then on (minus ClockSkew)
+}
+
+// ClockSkew: How far a panel's clock may run behind ours for Filter.Since
+const ClockSkew = 2 * time.Minute
+
+type waiter struct {
+	filter Filter
+	found  chan provider.SMSRecord // buffered(1)
+}
+
+// snapshot: Last successful poll of one provider
+type snapshot struct {
+	pollMu sync.Mutex // One upstream poll at a time per provider
+
+	mu       sync.RWMutex
+	feed     provider.SMSFeed
+	keys     map[string]bool
+	ok       bool      // At least one successful poll
+	polledAt time.Time // Last successful poll
+	lastErr  error
+	interval time.Duration
+}
+
+// Status: Per-provider scheduler state for /poller/status
+type Status struct {
+	Provider  string    `json:"provider"`
+	Interval  string    `json:"interval"`
+	PolledAt  time.Time `json:"polled_at,omitempty"`
+	Records   int       `json:"records"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Ledger remembers which messages were already emitted, so a restart
+// doesn't replay them. Unseen returns the records it has not stored (none
+// the first time it sees a provider at all: baseline, don't replay the
+// panel's backlog); Save stores them once the listeners have them. A crash
+// between the two emits those records again: at-least-once, not lost.
+type Ledger interface {
+	Unseen(provider string, records []provider.SMSRecord) ([]provider.SMSRecord, error)
+	Save(provider string, records []provider.SMSRecord) error
+}
+
+type Poller struct {
+	Interval time.Duration
+
+	mu        sync.Mutex
+	waiters   map[*waiter]struct{}
+	listeners []func(provider.SMSRecord)
+	ledger    Ledger
+	snaps     map[string]*snapshot
+	intervals map[string]time.Duration
+}
+
+func New(interval time.Duration) *Poller {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Poller{
+		Interval:  interval,
+		waiters:   map[*waiter]struct{}{},
+		snaps:     map[string]*snapshot{},
+		intervals: map[string]time.Duration{},
+	}
+}
+
+// SetInterval overrides the poll interval of one provider (call before Start).
+func (p *Poller) SetInterval(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.intervals[name] = d
+}
+
+// Start launches one polling goroutine per registered provider.
+func (p *Poller) Start(ctx context.Context) {
+	for _, prov := range provider.All() {
+		snap := p.snap(prov.Name())
+		fmt.Printf("[Poller] %s every %s\n", prov.Name(), snap.interval)
+		go p.run(ctx, prov, snap)
+	}
+}
+
+func (p *Poller) run(ctx context.Context, prov provider.Provider, snap *snapshot) {
+	p.poll(prov, snap)
+
+	ticker := time.NewTicker(snap.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(prov, snap)
+		}
+	}
+}
+
+func (p *Poller) snap(name string) *snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.snaps[name]
+	if !ok {
+		s = &snapshot{interval: p.Interval}
+		if d, ok := p.intervals[name]; ok && d > 0 {
+			s.interval = d
+		}
+		p.snaps[name] = s
+	}
+	return s
+}
+
+// poll: One upstream fetch. The first successful poll is only a baseline;
+// after that, records missing from the previous snapshot are "new".
+func (p *Poller) poll(prov provider.Provider, snap *snapshot) {
+	snap.pollMu.Lock()
+	defer snap.pollMu.Unlock()
+	p.pollLocked(prov, snap)
+}
+
+// pollLocked: poll body, caller holds snap.pollMu
+func (p *Poller) pollLocked(prov provider.Provider, snap *snapshot) {
+	feed, err := prov.PollSMS()
+	if err != nil {
+		fmt.Printf("[Poller] %s: %v\n", prov.Name(), err)
+		snap.mu.Lock()
+		snap.lastErr = err
+		snap.mu.Unlock()
+		return
+	}
+
+	current := make(map[string]bool, len(feed.Records))
+	for _, rec := range feed.Records {
+		current[key(rec)] = true
+	}
+
+	snap.mu.Lock()
+	previous, baseline := snap.keys, !snap.ok
+	snap.feed, snap.keys, snap.ok = feed, current, true
+	snap.polledAt, snap.lastErr = time.Now(), nil
+	snap.mu.Unlock()
+
+	fresh := p.fresh(prov.Name(), feed.Records, previous, baseline)
+	if len(fresh) > 0 {
+		fmt.Printf("[Poller] %s: %d new SMS\n", prov.Name(), len(fresh))
+		p.deliver(fresh)
+	}
+
+	// Only after the hand-off, so a crash can't swallow new records
+	if ledger := p.getLedger(); ledger != nil {
+		if err := ledger.Save(prov.Name(), feed.Records); err != nil {
+			fmt.Printf("[Poller] %s: ledger: %v\n", prov.Name(), err)
+		}
+	}
+}
+
+func (p *Poller) getLedger() Ledger {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ledger
+}
+
+// fresh: Records never emitted before. With a ledger this survives restarts;
+// without one (or if it fails) it is a diff against the previous poll.
+func (p *Poller) fresh(name string, records []provider.SMSRecord, previous map[string]bool, baseline bool) []provider.SMSRecord {
+	if ledger := p.getLedger(); ledger != nil {
+		fresh, err := ledger.Unseen(name, records)
+		if err == nil {
+			return fresh
+		}
+		fmt.Printf("[Poller] %s: ledger: %v\n", name, err)
+	}
+
+	if baseline {
+		return nil
+	}
+	var fresh []provider.SMSRecord
+	for _, rec := range records {
+		if !previous[key(rec)] {
+			fresh = append(fresh, rec)
+		}
+	}
+	return fresh
+}
+
+// SMS returns the snapshot of a provider. Before the first successful poll
+// it polls once itself; concurrent callers share that one upstream fetch.
+func (p *Poller) SMS(prov provider.Provider) (provider.SMSFeed, time.Time, error) {
+	snap := p.snap(prov.Name())
+
+	snap.mu.RLock()
+	feed, at, ok := snap.feed, snap.polledAt, snap.ok
+	snap.mu.RUnlock()
+	if ok {
+		return feed, at, nil
+	}
+
+	// Whoever gets pollMu first fetches, the rest find the snapshot filled
+	snap.pollMu.Lock()
+	snap.mu.RLock()
+	ok = snap.ok
+	snap.mu.RUnlock()
+	if !ok {
+		p.pollLocked(prov, snap)
+	}
+	snap.pollMu.Unlock()
+
+	snap.mu.RLock()
+	defer snap.mu.RUnlock()
+	if !snap.ok {
+		if snap.lastErr != nil {
+			return provider.SMSFeed{}, time.Time{}, snap.lastErr
+		}
+		return provider.SMSFeed{}, time.Time{}, fmt.Errorf("%s: %w", prov.Name(), provider.ErrNotReady)
+	}
+	return snap.feed, snap.polledAt, nil
+}
+
+func (p *Poller) Status() []Status {
+	var out []Status
+	for _, prov := range provider.All() {
+		snap := p.snap(prov.Name())
+		snap.mu.RLock()
+		st := Status{
+			Provider: prov.Name(),
+			Interval: snap.interval.String(),
+			PolledAt: snap.polledAt,
+			Records:  len(snap.feed.Records),
+		}
+		if snap.lastErr != nil {
+			st.LastError = snap.lastErr.Error()
+		}
+		snap.mu.RUnlock()
+		out = append(out, st)
+	}
+	return out
+}
+
+// ---------------------- WAITERS / LISTENERS ----------------------
+
+// OnNew registers fn to be called once for every new SMS (webhooks, streams).
+// fn runs on the polling goroutine and must not block.
+func (p *Poller) OnNew(fn func(provider.SMSRecord)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listeners = append(p.listeners, fn)
+}
+
+// SetLedger makes new-message detection persistent (see Ledger).
+func (p *Poller) SetLedger(l Ledger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ledger = l
+}
+
+// Wait blocks until a new SMS matching f is detected, or ctx ends.
+func (p *Poller) Wait(ctx context.Context, f Filter) (provider.SMSRecord, error) {
+	w := &waiter{filter: f, found: make(chan provider.SMSRecord, 1)}
+
+	p.mu.Lock()
+	p.waiters[w] = struct{}{}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.waiters, w)
+		p.mu.Unlock()
+	}()
+
+	select {
+	case rec := <-w.found:
+		return rec, nil
+	case <-ctx.Done():
+		return provider.SMSRecord{}, ctx.Err()
+	}
+}
+
+// deliver: Hands new records to every matching waiter, then to listeners
+func (p *Poller) deliver(records []provider.SMSRecord) {
+	p.mu.Lock()
+	for _, rec := range records {
+		for w := range p.waiters {
+			if !w.filter.Match(rec) {
+				continue
+			}
+			select {
+			case w.found <- rec:
+			default: // Already has a result
+			}
+		}
+	}
+	listeners := append([]func(provider.SMSRecord){}, p.listeners...)
+	p.mu.Unlock()
+
+	for _, rec := range records {
+		for _, fn := range listeners {
+			fn(rec)
+		}
+	}
+}
+
+func key(rec provider.SMSRecord) string {
+	if rec.ID != "" {
+		return rec.ID
+	}
+	return provider.Fingerprint(rec)
+}
+
+// ---------------------- MATCHING ----------------------
+
+func (f Filter) Match(rec provider.SMSRecord) bool {
+	// A backlog showing up late (panel recovered) is still old news;
+	// records without a parseable time can't be judged and pass
+	if !f.Since.IsZero() && !rec.Time.IsZero() && rec.Time.Before(f.Since.Add(-ClockSkew)) {
+		return false
+	}
+	if f.Number != "" && !SameNumber(f.Number, rec.Number) {
+		return false
+	}
+	if f.Service != "" {
+		want := strings.ToLower(f.Service)
+		hay := strings.ToLower(rec.Service + " " + rec.Message)
+		if rec.OTP != nil {
+			hay += " " + strings.ToLower(rec.OTP.Service)
+		}
+		if !strings.Contains(hay, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// SameNumber: Equal digits, or one ends with the other (missing country code)
+func SameNumber(a, b string) bool {
+	a, b = digits(a), digits(b)
+	if a == "" || b == "" {
+		return false
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return a == b || (len(b) >= 6 && strings.HasSuffix(a, b))
+}
+
+func digits(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
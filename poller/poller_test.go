@@ -0,0 +1,220 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myproject/provider"
+)
+
+func TestSameNumber(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"+213 551 234567", "213551234567", true},
+		{"213551234567", "551234567", true}, // Missing country code
+		{"213551234567", "34567", false},    // Too short to be a suffix match
+		{"213551234567", "1", false},
+		{"213551234567", "213551234568", false},
+		{"", "213551234567", false},
+	}
+	for _, tt := range tests {
+		if got := SameNumber(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameNumber(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFilterSince(t *testing.T) {
+	start := time.Now()
+	f := Filter{Number: "213551234567", Since: start}
+	rec := func(at time.Time) provider.SMSRecord {
+		return provider.SMSRecord{Number: "213551234567", Time: at}
+	}
+
+	if f.Match(rec(start.Add(-time.Hour))) {
+		t.Error("SMS from before the wait matched")
+	}
+	if !f.Match(rec(start.Add(-ClockSkew / 2))) {
+		t.Error("SMS within the clock skew did not match")
+	}
+	if !f.Match(rec(start.Add(time.Second))) {
+		t.Error("new SMS did not match")
+	}
+	if !f.Match(rec(time.Time{})) {
+		t.Error("SMS without a time did not match")
+	}
+}
+
+// A backlog delivered after the wait started must not satisfy it
+func TestWaitSkipsBacklog(t *testing.T) {
+	p := New(time.Minute)
+	start := time.Now()
+	got := make(chan provider.SMSRecord, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		rec, _ := p.Wait(ctx, Filter{Number: "213551234567", Since: start})
+		got <- rec
+	}()
+
+	for !p.hasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	p.deliver([]provider.SMSRecord{
+		{ID: "old", Number: "213551234567", Time: start.Add(-time.Hour)},
+		{ID: "new", Number: "213551234567", Time: start.Add(time.Second)},
+	})
+	if rec := <-got; rec.ID != "new" {
+		t.Errorf("Wait returned %q, want the new SMS", rec.ID)
+	}
+}
+
+func (p *Poller) hasWaiters() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.waiters) > 0
+}
+
+// feedProvider: PollSMS returns whatever records (or err) holds
+type feedProvider struct {
+	name    string
+	records []provider.SMSRecord
+	err     error
+	polls   atomic.Int32
+}
+
+func (f *feedProvider) Name() string                            { return f.name }
+func (f *feedProvider) Login() error                            { return nil }
+func (f *feedProvider) GetSMSLogs() ([]byte, error)             { return nil, nil }
+func (f *feedProvider) FetchSMS() ([]provider.SMSRecord, error) { return f.records, nil }
+func (f *feedProvider) PollSMS() (provider.SMSFeed, error) {
+	f.polls.Add(1)
+	return provider.SMSFeed{Records: f.records}, f.err
+}
+func (f *feedProvider) GetNumberStats() ([]byte, error)                { return nil, nil }
+func (f *feedProvider) FetchNumbers() ([]provider.NumberRecord, error) { return nil, nil }
+func (f *feedProvider) Health() provider.Health                        { return provider.Health{Name: f.name} }
+
+// memLedger: Ledger in a map, logging the order of calls
+type memLedger struct {
+	known map[string]bool
+	seen  map[string]bool
+	log   *[]string
+}
+
+func (l *memLedger) Unseen(name string, records []provider.SMSRecord) ([]provider.SMSRecord, error) {
+	if !l.known[name] {
+		return nil, nil
+	}
+	var out []provider.SMSRecord
+	for _, rec := range records {
+		if !l.seen[rec.ID] {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (l *memLedger) Save(name string, records []provider.SMSRecord) error {
+	l.known[name] = true
+	for _, rec := range records {
+		l.seen[rec.ID] = true
+	}
+	*l.log = append(*l.log, "save")
+	return nil
+}
+
+// Records are saved to the ledger only after the listeners got them, so a
+// crash in between re-emits instead of losing them
+func TestLedgerSavesAfterDelivery(t *testing.T) {
+	var calls []string
+	p := New(time.Minute)
+	p.SetLedger(&memLedger{known: map[string]bool{}, seen: map[string]bool{}, log: &calls})
+	p.OnNew(func(rec provider.SMSRecord) { calls = append(calls, "new "+rec.ID) })
+
+	prov := &feedProvider{name: "ledger-test", records: []provider.SMSRecord{{ID: "a"}}}
+	snap := p.snap(prov.Name())
+	p.poll(prov, snap) // Baseline: nothing emitted
+	prov.records = append(prov.records, provider.SMSRecord{ID: "b"})
+	p.poll(prov, snap)
+	p.poll(prov, snap) // Nothing new
+
+	want := []string{"save", "new b", "save", "save"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+// Without a ledger new SMS are the ones missing from the previous poll; the
+// first poll is only the baseline
+func TestPollDiff(t *testing.T) {
+	p := New(time.Minute)
+	var got []string
+	p.OnNew(func(rec provider.SMSRecord) { got = append(got, rec.ID) })
+
+	prov := &feedProvider{name: "diff-test"}
+	snap := p.snap(prov.Name())
+	steps := []struct {
+		name    string
+		records []string
+		err     error
+		want    []string
+	}{
+		{"baseline", []string{"a", "b"}, nil, nil},
+		{"unchanged", []string{"a", "b"}, nil, nil},
+		{"new on top", []string{"c", "a", "b"}, nil, []string{"c"}},
+		{"oldest rotated out", []string{"e", "d", "c", "a"}, nil, []string{"e", "d"}},
+		{"failed poll", nil, errors.New("HTTP 502"), nil},
+		{"after the failure", []string{"f", "e", "d", "c", "a"}, nil, []string{"f"}},
+	}
+	for _, step := range steps {
+		prov.records, prov.err = nil, step.err
+		for _, id := range step.records {
+			prov.records = append(prov.records, provider.SMSRecord{ID: id})
+		}
+		got = nil
+		p.poll(prov, snap)
+		if strings.Join(got, ",") != strings.Join(step.want, ",") {
+			t.Errorf("%s: new = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+// Reads are served from the snapshot: one upstream poll for any number of
+// readers, and a failed poll keeps the last good snapshot
+func TestSMSFromSnapshot(t *testing.T) {
+	p := New(time.Minute)
+	prov := &feedProvider{name: "snapshot-test", records: []provider.SMSRecord{{ID: "a"}}}
+
+	for i := 0; i < 5; i++ {
+		feed, at, err := p.SMS(prov)
+		if err != nil || len(feed.Records) != 1 || at.IsZero() {
+			t.Fatalf("SMS = %v, %s, %v", feed.Records, at, err)
+		}
+	}
+	if n := prov.polls.Load(); n != 1 {
+		t.Errorf("%d upstream polls for 5 reads, want 1", n)
+	}
+
+	prov.err = errors.New("HTTP 502")
+	p.poll(prov, p.snap(prov.Name()))
+	if feed, _, err := p.SMS(prov); err != nil || len(feed.Records) != 1 {
+		t.Errorf("after a failed poll: %v, %v; want the last snapshot", feed.Records, err)
+	}
+
+	down := &feedProvider{name: "snapshot-down", err: errors.New("HTTP 502")}
+	if _, _, err := p.SMS(down); err == nil {
+		t.Error("no error before the first good poll")
+	}
+}
@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Upstream failure kinds. Drivers wrap these (fmt.Errorf("%w: ...")) so the
+// HTTP layer can answer with a proper status and a machine-readable code
+// instead of a blanket 500. See Classify.
+var (
+	ErrCaptcha         = errors.New("captcha not solved")
+	ErrAuthFailed      = errors.New("login failed")
+	ErrSessionExpired  = errors.New("session expired and re-login did not help")
+	ErrUpstreamTimeout = errors.New("panel timed out")
+	ErrUpstreamDown    = errors.New("panel unreachable")
+	ErrBadResponse     = errors.New("unexpected panel response")
+	ErrNotReady        = errors.New("no data yet")
+)
+
+// ErrBlocked: The panel banned our IP (403). Retry once RetryAfter has passed.
+type ErrBlocked struct {
+	Provider   string
+	Reason     string // Where the block was seen: "403", "api", "at_reports"
+	RetryAfter time.Duration
+}
+
+func (e *ErrBlocked) Error() string {
+	return fmt.Sprintf("%s: server blocked IP (%s), retry in %ds", e.Provider, e.Reason, int(e.RetryAfter.Seconds()))
+}
+
+// Upstream wraps a transport error from talking to a panel as
+// ErrUpstreamTimeout or ErrUpstreamDown.
+func Upstream(err error) error {
+	if err == nil {
+		return nil
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %v", ErrUpstreamTimeout, err)
+	}
+	return fmt.Errorf("%w: %v", ErrUpstreamDown, err)
+}
+
+// ErrorInfo: How an error is reported to API clients
+type ErrorInfo struct {
+	Status     int
+	Code       string
+	RetryAfter time.Duration // > 0: send Retry-After
+}
+
+// Classify maps an error to its HTTP status and code.
+func Classify(err error) ErrorInfo {
+	var blocked *ErrBlocked
+	switch {
+	case errors.As(err, &blocked):
+		retry := blocked.RetryAfter
+		if retry < time.Second {
+			retry = time.Second
+		}
+		return ErrorInfo{Status: http.StatusServiceUnavailable, Code: "blocked", RetryAfter: retry}
+	case errors.Is(err, ErrNotReady):
+		return ErrorInfo{Status: http.StatusServiceUnavailable, Code: "not_ready", RetryAfter: 5 * time.Second}
+	case errors.Is(err, ErrUpstreamTimeout):
+		return ErrorInfo{Status: http.StatusGatewayTimeout, Code: "upstream_timeout"}
+	case errors.Is(err, ErrUpstreamDown):
+		return ErrorInfo{Status: http.StatusBadGateway, Code: "upstream_unavailable"}
+	case errors.Is(err, ErrCaptcha):
+		return ErrorInfo{Status: http.StatusBadGateway, Code: "captcha_failed"}
+	case errors.Is(err, ErrAuthFailed):
+		return ErrorInfo{Status: http.StatusBadGateway, Code: "auth_failed"}
+	case errors.Is(err, ErrSessionExpired):
+		return ErrorInfo{Status: http.StatusBadGateway, Code: "session_expired"}
+	case errors.Is(err, ErrBadResponse):
+		return ErrorInfo{Status: http.StatusBadGateway, Code: "bad_upstream_response"}
+	}
+	return ErrorInfo{Status: http.StatusInternalServerError, Code: "internal"}
+}
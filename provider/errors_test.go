@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("mait: %w: details", err) }
+	tests := []struct {
+		err    error
+		status int
+		code   string
+		retry  time.Duration
+	}{
+		{&ErrBlocked{Provider: "mait", Reason: "403", RetryAfter: 90 * time.Second}, http.StatusServiceUnavailable, "blocked", 90 * time.Second},
+		{fmt.Errorf("poll: %w", &ErrBlocked{RetryAfter: 90 * time.Second}), http.StatusServiceUnavailable, "blocked", 90 * time.Second},
+		{&ErrBlocked{RetryAfter: 200 * time.Millisecond}, http.StatusServiceUnavailable, "blocked", time.Second}, // Never Retry-After: 0
+		{wrap(ErrNotReady), http.StatusServiceUnavailable, "not_ready", 5 * time.Second},
+		{wrap(ErrUpstreamTimeout), http.StatusGatewayTimeout, "upstream_timeout", 0},
+		{wrap(ErrUpstreamDown), http.StatusBadGateway, "upstream_unavailable", 0},
+		{wrap(ErrCaptcha), http.StatusBadGateway, "captcha_failed", 0},
+		{wrap(ErrAuthFailed), http.StatusBadGateway, "auth_failed", 0},
+		{wrap(ErrSessionExpired), http.StatusBadGateway, "session_expired", 0},
+		{wrap(ErrBadResponse), http.StatusBadGateway, "bad_upstream_response", 0},
+		{errors.New("something else"), http.StatusInternalServerError, "internal", 0},
+	}
+	for _, tt := range tests {
+		got := Classify(tt.err)
+		if got.Status != tt.status || got.Code != tt.code || got.RetryAfter != tt.retry {
+			t.Errorf("Classify(%v) = %+v, want %d %s retry %s", tt.err, got, tt.status, tt.code, tt.retry)
+		}
+	}
+}
+
+// timeoutErr: net.Error that timed out
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestUpstream(t *testing.T) {
+	if Upstream(nil) != nil {
+		t.Error("Upstream(nil) != nil")
+	}
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{context.DeadlineExceeded, ErrUpstreamTimeout},
+		{&net.OpError{Op: "dial", Err: timeoutErr{}}, ErrUpstreamTimeout},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrUpstreamDown},
+		{errors.New("EOF"), ErrUpstreamDown},
+	}
+	for _, tt := range tests {
+		if got := Upstream(tt.err); !errors.Is(got, tt.want) {
+			t.Errorf("Upstream(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
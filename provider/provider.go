@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"myproject/otp"
+)
+
+// Provider is the common surface every panel client exposes.
+// main.go mounts /<Name()>/sms, /<Name()>/numbers and their /v2/ variants for each registered one.
+type Provider interface {
+	Name() string                          // URL prefix, e.g. "d-group"
+	Login() error                          // Force a fresh login (drops old session)
+	GetSMSLogs() ([]byte, error)           // Cleaned DataTables JSON (legacy view)
+	FetchSMS() ([]SMSRecord, error)        // Typed records (v2 view)
+	PollSMS() (SMSFeed, error)             // Both of the above from one upstream fetch
+	GetNumberStats() ([]byte, error)       // Cleaned DataTables JSON (legacy view)
+	FetchNumbers() ([]NumberRecord, error) // Typed inventory (v2 view)
+	Health() Health
+}
+
+// MultiAccount is implemented by providers holding more than one login.
+// Account returns the provider for a single login (used by ?account=).
+type MultiAccount interface {
+	Accounts() []string
+	Account(name string) (Provider, bool)
+}
+
+// Health is a cheap snapshot of a provider's session state (no upstream call).
+type Health struct {
+	Name     string   `json:"name"`
+	Account  string   `json:"account,omitempty"`
+	LoggedIn bool     `json:"logged_in"`
+	Blocked  bool     `json:"blocked"`
+	Accounts []Health `json:"accounts,omitempty"`
+}
+
+// =========================================================
+// REGISTRY (Panels register themselves from init)
+// =========================================================
+var (
+	registry []Provider
+	regMutex sync.RWMutex
+)
+
+// Register adds a provider. Duplicate names are a programming error.
+func Register(p Provider) {
+	regMutex.Lock()
+	defer regMutex.Unlock()
+
+	for _, existing := range registry {
+		if existing.Name() == p.Name() {
+			panic(fmt.Sprintf("provider: %q registered twice", p.Name()))
+		}
+	}
+	registry = append(registry, p)
+}
+
+// All returns providers in registration order.
+func All() []Provider {
+	regMutex.RLock()
+	defer regMutex.RUnlock()
+
+	out := make([]Provider, len(registry))
+	copy(out, registry)
+	return out
+}
+
+// Get looks up a provider by its URL name.
+func Get(name string) (Provider, bool) {
+	regMutex.RLock()
+	defer regMutex.RUnlock()
+
+	for _, p := range registry {
+		if p.Name() == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// SMSFeed holds both SMS views built from the same upstream response.
+type SMSFeed struct {
+	Legacy  []byte // DataTables JSON, as GetSMSLogs
+	Records []SMSRecord
+}
+
+// SMSRecord is the typed form of one CDR row, the same for every panel.
+type SMSRecord struct {
+	ID       string    `json:"id"` // Fingerprint, stable across polls and restarts
+	Time     time.Time `json:"time"`
+	Range    string    `json:"range"`
+	Country  string    `json:"country,omitempty"` // ISO 3166 alpha-2, derived from the number
+	Number   string    `json:"number"`
+	Service  string    `json:"service"` // CLI / sender
+	Message  string    `json:"message"`
+	Currency string    `json:"currency"`
+	Cost     float64   `json:"cost"`
+	Status   string    `json:"status,omitempty"`
+	Provider string    `json:"provider"`
+	Account  string    `json:"account,omitempty"`
+
+	OTP *otp.Result `json:"otp,omitempty"` // Extracted code, nil if none found
+}
+
+// Fingerprint: Stable message ID from provider, account, number, timestamp
+// and a hash of the message. The time is hashed as the panel's wall clock so
+// a changed server time zone doesn't change IDs.
+func Fingerprint(rec SMSRecord) string {
+	msg := sha256.Sum256([]byte(rec.Message))
+	stamp := ""
+	if !rec.Time.IsZero() {
+		stamp = rec.Time.Format("2006-01-02 15:04:05")
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		rec.Provider, rec.Account, rec.Number, stamp, hex.EncodeToString(msg[:]),
+	}, "|")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Period is the billing period of a number.
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+	PeriodUnknown Period = "unknown"
+)
+
+// ParsePeriod maps panel text ("Weekly", "<b>Monthly</b>") to a Period.
+func ParsePeriod(s string) Period {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "month"):
+		return PeriodMonthly
+	case strings.Contains(s, "week"):
+		return PeriodWeekly
+	case strings.Contains(s, "day"), strings.Contains(s, "daily"):
+		return PeriodDaily
+	}
+	return PeriodUnknown
+}
+
+// NumberRecord is the typed form of one inventory row.
+type NumberRecord struct {
+	Number      string             `json:"number"`            // E.164, e.g. "+213555123456"
+	Country     string             `json:"country,omitempty"` // ISO 3166 alpha-2
+	CallingCode int                `json:"calling_code,omitempty"`
+	Range       string             `json:"range"`
+	Period      Period             `json:"period"`
+	Price       float64            `json:"price"`
+	Currency    string             `json:"currency"`
+	Stats       map[string]float64 `json:"stats,omitempty"` // Parsed from StatsText
+	StatsText   string             `json:"stats_text,omitempty"`
+	Provider    string             `json:"provider"`
+	Account     string             `json:"account,omitempty"`
+}
@@ -0,0 +1,20 @@
+package provider
+
+import "testing"
+
+func TestParsePeriod(t *testing.T) {
+	tests := map[string]Period{
+		"Daily":           PeriodDaily,
+		"1 day":           PeriodDaily,
+		"Weekly":          PeriodWeekly,
+		"<b>Monthly</b>":  PeriodMonthly,
+		"30 days (month)": PeriodMonthly,
+		"":                PeriodUnknown,
+		"per activation":  PeriodUnknown,
+	}
+	for in, want := range tests {
+		if got := ParsePeriod(in); got != want {
+			t.Errorf("ParsePeriod(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
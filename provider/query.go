@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// MaxSMSLimit caps how many rows one live query may pull from a panel.
+const MaxSMSLimit = 5000
+
+// SMSQuery is a live CDR search. Zero values mean the panel defaults
+// (its configured date window, first page, newest first).
+type SMSQuery struct {
+	From   time.Time // fdate1
+	To     time.Time // fdate2
+	Offset int       // iDisplayStart
+	Limit  int       // Rows wanted; more than one panel page is fetched page by page
+	Sort   string    // "desc" (newest first) or "asc"
+
+	Number string // fnum, digits contained in the number
+	Range  string // frange, substring of the range name
+	Sender string // fcli, substring of the sender / service
+	Text   string // sSearch, substring of any column
+}
+
+// Ascending: Oldest first
+func (q SMSQuery) Ascending() bool { return q.Sort == "asc" }
+
+// Window returns rows[offset:offset+limit]; limit <= 0 means everything
+// after offset. The result shares rows' backing array.
+func Window[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) {
+		return rows[:0]
+	}
+	rows = rows[offset:]
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// Querier is implemented by providers that can run an SMSQuery against the
+// panel instead of serving their default page.
+type Querier interface {
+	QuerySMS(q SMSQuery) (SMSFeed, error)
+}
+
+// PanelLocation is the zone of the panels' wall-clock times. Set once at
+// startup from the config; fixed so record times (and the history keys
+// built from them) don't depend on the server's TZ.
+var PanelLocation = time.UTC
+
+// ParseTime reads unix seconds, RFC3339, "2006-01-02 15:04:05" or
+// "2006-01-02". Zone-less values are in PanelLocation, like SMSRecord.Time;
+// with endOfDay a bare date covers the whole day, so to=2024-05-01 includes it.
+func ParseTime(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", v, PanelLocation); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, PanelLocation); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.New("invalid time: " + v)
+}
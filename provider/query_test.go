@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+	rows := []int{0, 1, 2, 3, 4}
+	for _, tc := range []struct {
+		offset, limit int
+		want          []int
+	}{
+		{0, 0, []int{0, 1, 2, 3, 4}},
+		{0, 2, []int{0, 1}},
+		{3, 0, []int{3, 4}},
+		{3, 10, []int{3, 4}},
+		{2, -1, []int{2, 3, 4}},
+		{5, 1, []int{}},
+		{9, 0, []int{}},
+	} {
+		if got := Window(rows, tc.offset, tc.limit); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Window(%d, %d) = %v, want %v", tc.offset, tc.limit, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"myproject/config"
+	"myproject/poller"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pick: ?account=<username> targets one login of a multi-account panel
+func pick(c *gin.Context, p provider.Provider) (provider.Provider, bool) {
+	account := c.Query("account")
+	if account == "" {
+		return p, true
+	}
+	if multi, ok := p.(provider.MultiAccount); ok {
+		if one, ok := multi.Account(account); ok {
+			return one, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "unknown account: " + account, "code": "unknown_account"})
+	return nil, false
+}
+
+// mountProvider: SMS reads come from the poller snapshot; ?account= on the
+// legacy route, the numbers routes and SMS queries (see smsQuery) still go
+// to the panel
+func mountProvider(r *gin.Engine, p provider.Provider, sched *poller.Poller) {
+	r.GET("/"+p.Name()+"/sms", func(c *gin.Context) {
+		target, ok := pick(c, p)
+		if !ok {
+			return
+		}
+		if q, live, ok := smsQuery(c); !ok {
+			return
+		} else if live {
+			feed, err := querySMS(target, q)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			c.Data(http.StatusOK, "application/json", feed.Legacy)
+			return
+		}
+		if target != p {
+			data, err := target.GetSMSLogs()
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			c.Data(http.StatusOK, "application/json", data)
+			return
+		}
+
+		feed, polledAt, err := sched.SMS(p)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		setSnapshotAge(c, polledAt)
+		c.Data(http.StatusOK, "application/json", feed.Legacy)
+	})
+
+	r.GET("/"+p.Name()+"/numbers", func(c *gin.Context) {
+		target, ok := pick(c, p)
+		if !ok {
+			return
+		}
+		data, err := target.GetNumberStats()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", data)
+	})
+
+	// ================= V2 (Typed records) =================
+	r.GET("/v2/"+p.Name()+"/sms", func(c *gin.Context) {
+		target, ok := pick(c, p)
+		if !ok {
+			return
+		}
+		q, live, ok := smsQuery(c)
+		if !ok {
+			return
+		}
+
+		var records []provider.SMSRecord
+		if live {
+			feed, err := querySMS(target, q)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			records = feed.Records
+		} else {
+			feed, polledAt, err := sched.SMS(p)
+			if err != nil {
+				respondError(c, err)
+				return
+			}
+			setSnapshotAge(c, polledAt)
+			records = feed.Records
+			if account := c.Query("account"); account != "" {
+				records = byAccount(records, account)
+			}
+		}
+		if c.Query("only_otp") == "true" {
+			records = onlyOTP(records)
+		}
+		c.JSON(http.StatusOK, gin.H{"provider": p.Name(), "count": len(records), "records": records})
+	})
+
+	r.GET("/v2/"+p.Name()+"/numbers", func(c *gin.Context) {
+		target, ok := pick(c, p)
+		if !ok {
+			return
+		}
+		records, err := target.FetchNumbers()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"provider": p.Name(), "count": len(records), "records": records})
+	})
+}
+
+// smsQuery: ?from= &to= &offset= &limit= &sort=asc|desc and the filters
+// ?number= &range= &sender= &q=. live is false when none is given (the
+// poller snapshot is served). Bad values get a 400.
+func smsQuery(c *gin.Context) (q provider.SMSQuery, live bool, ok bool) {
+	for _, name := range []string{"from", "to", "offset", "limit", "sort", "number", "range", "sender", "q"} {
+		if c.Query(name) != "" {
+			live = true
+		}
+	}
+	if !live {
+		return q, false, true
+	}
+	q.Number, q.Range, q.Sender, q.Text = c.Query("number"), c.Query("range"), c.Query("sender"), c.Query("q")
+
+	var err error
+	if q.From, err = provider.ParseTime(c.Query("from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error(), "code": "bad_request"})
+		return q, true, false
+	}
+	if q.To, err = provider.ParseTime(c.Query("to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error(), "code": "bad_request"})
+		return q, true, false
+	}
+	for name, dst := range map[string]*int{"offset": &q.Offset, "limit": &q.Limit} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + ": " + v, "code": "bad_request"})
+			return q, true, false
+		}
+		*dst = n
+	}
+	if q.Limit > provider.MaxSMSLimit {
+		q.Limit = provider.MaxSMSLimit
+	}
+	switch q.Sort = c.DefaultQuery("sort", "desc"); q.Sort {
+	case "asc", "desc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be asc or desc", "code": "bad_request"})
+		return q, true, false
+	}
+	return q, true, true
+}
+
+// querySMS: Live panel query, for providers that support one
+func querySMS(p provider.Provider, q provider.SMSQuery) (provider.SMSFeed, error) {
+	querier, ok := p.(provider.Querier)
+	if !ok {
+		return provider.SMSFeed{}, errors.New(p.Name() + ": live SMS queries not supported")
+	}
+	return querier.QuerySMS(q)
+}
+
+// mountAliases: /<alias>/... and /v2/<alias>/... for every account with an
+// alias, answered by the panel's routes with ?account=<username>. Accounts
+// used to be separate panels (number-panel-1), this keeps their URLs working.
+func mountAliases(r *gin.Engine, cfg *config.Config) {
+	for _, p := range cfg.Panels {
+		if _, ok := provider.Get(p.Name); !ok {
+			continue
+		}
+		for _, a := range p.Accounts {
+			if a.Alias == "" {
+				continue
+			}
+			if _, taken := provider.Get(a.Alias); taken {
+				log.Printf("[Config] alias %s is also a panel name, skipping", a.Alias)
+				continue
+			}
+			mountAlias(r, a.Alias, p.Name, a.Username)
+		}
+	}
+}
+
+func mountAlias(r *gin.Engine, alias, name, username string) {
+	forward := func(prefix string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			q := c.Request.URL.Query()
+			q.Set("account", username)
+			c.Request.URL.Path = prefix + name + c.Param("route")
+			c.Request.URL.RawQuery = q.Encode()
+			r.HandleContext(c)
+		}
+	}
+	r.GET("/"+alias+"/*route", forward("/"))
+	r.GET("/v2/"+alias+"/*route", forward("/v2/"))
+}
+
+// setSnapshotAge: Lets clients see how old the served snapshot is
+func setSnapshotAge(c *gin.Context, polledAt time.Time) {
+	c.Header("X-Snapshot-Age", strconv.Itoa(int(time.Since(polledAt).Seconds())))
+}
+
+func byAccount(records []provider.SMSRecord, account string) []provider.SMSRecord {
+	out := []provider.SMSRecord{}
+	for _, rec := range records {
+		if rec.Account == account {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// onlyOTP: ?only_otp=true keeps records with an extracted code
+func onlyOTP(records []provider.SMSRecord) []provider.SMSRecord {
+	out := []provider.SMSRecord{}
+	for _, rec := range records {
+		if rec.OTP != nil {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// parseTimeout: "120s", "2m" or bare seconds ("90"), capped at 10 minutes
+func parseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, convErr := strconv.Atoi(s)
+		if convErr != nil {
+			return 0, errors.New("invalid timeout: " + s)
+		}
+		d = time.Duration(secs) * time.Second
+	}
+	if d <= 0 {
+		return 0, errors.New("timeout must be positive")
+	}
+	if d > 10*time.Minute {
+		d = 10 * time.Minute
+	}
+	return d, nil
+}
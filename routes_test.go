@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"myproject/config"
+	"myproject/poller"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubProvider: Canned answers, Name doubles as the account for sub-providers
+type stubProvider struct {
+	name     string
+	accounts map[string]*stubProvider
+}
+
+func (s *stubProvider) Name() string { return s.name }
+func (s *stubProvider) Login() error { return nil }
+func (s *stubProvider) GetSMSLogs() ([]byte, error) {
+	return []byte(`{"account":"` + s.name + `"}`), nil
+}
+func (s *stubProvider) FetchSMS() ([]provider.SMSRecord, error) { return nil, nil }
+func (s *stubProvider) PollSMS() (provider.SMSFeed, error)      { return provider.SMSFeed{}, nil }
+func (s *stubProvider) GetNumberStats() ([]byte, error) {
+	return []byte(`{"account":"` + s.name + `"}`), nil
+}
+func (s *stubProvider) FetchNumbers() ([]provider.NumberRecord, error) { return nil, nil }
+func (s *stubProvider) Health() provider.Health                        { return provider.Health{Name: s.name} }
+
+func (s *stubProvider) Accounts() []string { return nil }
+func (s *stubProvider) Account(name string) (provider.Provider, bool) {
+	a, ok := s.accounts[name]
+	return a, ok
+}
+
+func TestAccountAlias(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	p := &stubProvider{name: "alias-panel", accounts: map[string]*stubProvider{
+		"first":  {name: "first"},
+		"second": {name: "second"},
+	}}
+	provider.Register(p)
+
+	r := gin.New()
+	mountProvider(r, p, poller.New(time.Minute))
+	mountAliases(r, &config.Config{Panels: []config.Panel{{
+		Name:     "alias-panel",
+		Accounts: []config.Account{{Username: "first"}, {Username: "second", Alias: "alias-panel-1"}},
+	}}})
+
+	for _, path := range []string{"/alias-panel-1/numbers", "/alias-panel-1/sms"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != `{"account":"second"}` {
+			t.Errorf("GET %s = %d %s, want the second account", path, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Panel sessions saved across restarts, so a redeploy reuses the existing
+// logins instead of solving a captcha on every panel at once (mait blocks
+// the IP for that). The file is AES-256-GCM encrypted with a key derived
+// from SESSION_KEY; without a key nothing is written.
+//
+// The key is a single SHA-256 of SESSION_KEY, not a slow password KDF, so
+// a guessable value can be brute-forced from a copy of the file. Use a
+// long random string, e.g. `openssl rand -hex 32`.
+
+const DefaultPath = "sessions.enc"
+
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Session: Everything a panel client needs to skip the login
+type Session struct {
+	Panel      string    `json:"panel"`
+	Account    string    `json:"account"`
+	BaseURL    string    `json:"base_url"` // A changed URL invalidates the session
+	Token      string    `json:"token,omitempty"`
+	Cookies    []Cookie  `json:"cookies,omitempty"`
+	ObtainedAt time.Time `json:"obtained_at"`
+}
+
+type Store struct {
+	path string
+	aead cipher.AEAD
+
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func id(panel, account string) string { return panel + "/" + account }
+
+// Open loads the file at path (missing is fine). key should be high-entropy,
+// see the package comment.
+func Open(path, key string) (*Store, error) {
+	if key == "" {
+		return nil, errors.New("session: no key")
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{path: path, aead: aead, sessions: map[string]Session{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	if err := s.decode(data); err != nil {
+		// Wrong key or corrupt file: start empty, the next login rewrites it
+		fmt.Printf("[Session] %s: %v, starting without saved sessions\n", path, err)
+	}
+	return s, nil
+}
+
+func (s *Store) decode(data []byte) error {
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return errors.New("file too short")
+	}
+	plain, err := s.aead.Open(nil, data[:n], data[n:], nil)
+	if err != nil {
+		return errors.New("cannot decrypt (SESSION_KEY changed?)")
+	}
+	var list []Session
+	if err := json.Unmarshal(plain, &list); err != nil {
+		return err
+	}
+	for _, sess := range list {
+		s.sessions[id(sess.Panel, sess.Account)] = sess
+	}
+	return nil
+}
+
+// Get returns the saved session of one panel account.
+func (s *Store) Get(panel, account string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id(panel, account)]
+	return sess, ok
+}
+
+// Put saves sess and rewrites the file.
+func (s *Store) Put(sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id(sess.Panel, sess.Account)] = sess
+	return s.writeLocked()
+}
+
+// Delete forgets a session (after it turned out to be expired).
+func (s *Store) Delete(panel, account string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id(panel, account)]; !ok {
+		return nil
+	}
+	delete(s.sessions, id(panel, account))
+	return s.writeLocked()
+}
+
+// writeLocked: Encrypt and replace the file atomically
+func (s *Store) writeLocked() error {
+	list := make([]Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		list = append(list, sess)
+	}
+	plain, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := s.aead.Seal(nonce, nonce, plain, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sessions-*")
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	return nil
+}
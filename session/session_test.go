@@ -0,0 +1,117 @@
+package session
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func testSession(account string) Session {
+	return Session{
+		Panel:      "mait",
+		Account:    account,
+		BaseURL:    "http://panel.example",
+		Token:      "secret-token-" + account,
+		Cookies:    []Cookie{{Name: "PHPSESSID", Value: "cookie-" + account}},
+		ObtainedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func open(t *testing.T, path, key string) *Store {
+	t.Helper()
+	s, err := Open(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	s := open(t, path, testKey)
+	for _, account := range []string{"alice", "bob", "carol"} {
+		if err := s.Put(testSession(account)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Delete("mait", "carol"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret-token")) || bytes.Contains(data, []byte("cookie-")) {
+		t.Error("file holds the session in plain text")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode %v, want 0600", info.Mode().Perm())
+	}
+
+	s = open(t, path, testKey)
+	for _, account := range []string{"alice", "bob"} {
+		got, ok := s.Get("mait", account)
+		want := testSession(account)
+		if !ok || got.Token != want.Token || got.BaseURL != want.BaseURL ||
+			len(got.Cookies) != 1 || got.Cookies[0] != want.Cookies[0] || !got.ObtainedAt.Equal(want.ObtainedAt) {
+			t.Errorf("Get(%s) = %+v, %v; want %+v", account, got, ok, want)
+		}
+	}
+	if _, ok := s.Get("mait", "carol"); ok {
+		t.Error("deleted session came back")
+	}
+}
+
+// A changed SESSION_KEY is not fatal: the store starts empty and the next
+// login overwrites the file
+func TestWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	open(t, path, testKey).Put(testSession("alice"))
+
+	s, err := Open(path, "another key entirely, also 32+ chars")
+	if err != nil {
+		t.Fatalf("Open with another key: %v, want an empty store", err)
+	}
+	if _, ok := s.Get("mait", "alice"); ok {
+		t.Fatal("session readable with the wrong key")
+	}
+	if err := s.Put(testSession("bob")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := open(t, path, "another key entirely, also 32+ chars").Get("mait", "bob"); !ok {
+		t.Error("file not rewritten with the new key")
+	}
+}
+
+func TestTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.enc")
+	open(t, path, testKey).Put(testSession("alice"))
+	data, _ := os.ReadFile(path)
+
+	for name, bad := range map[string][]byte{
+		"flipped byte":  func() []byte { b := bytes.Clone(data); b[len(b)-1] ^= 1; return b }(),
+		"flipped nonce": func() []byte { b := bytes.Clone(data); b[0] ^= 1; return b }(),
+		"truncated":     data[:len(data)-4],
+		"too short":     data[:5],
+	} {
+		os.WriteFile(path, bad, 0o600)
+		s := open(t, path, testKey)
+		if err := s.decode(bad); err == nil {
+			t.Errorf("%s: decode accepted the file", name)
+		}
+		if _, ok := s.Get("mait", "alice"); ok {
+			t.Errorf("%s: session loaded from a tampered file", name)
+		}
+	}
+}
+
+func TestNoKey(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "sessions.enc"), ""); err == nil {
+		t.Error("Open without a key succeeded")
+	}
+}
@@ -0,0 +1,110 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"myproject/provider"
+)
+
+// Hub fans new SMS out to live connections (SSE, WebSocket). Every event
+// gets an increasing ID and the last BacklogSize events are kept, so a client
+// reconnecting with Last-Event-ID gets what it missed.
+
+const (
+	BacklogSize = 1000
+	subBuffer   = 64
+)
+
+type Event struct {
+	ID     uint64             `json:"id"`
+	Record provider.SMSRecord `json:"record"`
+}
+
+// Subscription: C is closed when the subscriber was too slow (its buffer
+// filled up) or after Close. A closed-for-slowness client should reconnect
+// with its last ID and will be replayed from the backlog.
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	hub     *Hub
+	dropped bool
+}
+
+type Hub struct {
+	mu      sync.Mutex
+	nextID  uint64
+	backlog []Event // Ring, oldest first
+	subs    map[*Subscription]struct{}
+	origins map[string]bool // Cross-origin pages allowed on /ws
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		// IDs start at boot time in ms*1000 so they keep increasing across restarts
+		nextID: uint64(time.Now().UnixMilli()) * 1000,
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Publish assigns an ID to rec and hands it to every subscriber.
+func (h *Hub) Publish(rec provider.SMSRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	ev := Event{ID: h.nextID, Record: rec}
+	h.backlog = append(h.backlog, ev)
+	if len(h.backlog) > BacklogSize {
+		h.backlog = h.backlog[len(h.backlog)-BacklogSize:]
+	}
+
+	for s := range h.subs {
+		select {
+		case s.ch <- ev:
+		default:
+			// Slow consumer: cut it loose instead of blocking the poller
+			s.dropped = true
+			close(s.ch)
+			delete(h.subs, s)
+		}
+	}
+}
+
+// Subscribe returns the backlog after lastID (0 = none) and a live feed.
+// Backlog and feed never overlap or leave a gap.
+func (h *Hub) Subscribe(lastID uint64) ([]Event, *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []Event
+	if lastID > 0 {
+		for _, ev := range h.backlog {
+			if ev.ID > lastID {
+				missed = append(missed, ev)
+			}
+		}
+	}
+
+	ch := make(chan Event, subBuffer)
+	s := &Subscription{C: ch, ch: ch, hub: h}
+	h.subs[s] = struct{}{}
+	return missed, s
+}
+
+// Close unsubscribes; safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.ch)
+	}
+}
+
+// Dropped reports whether the hub closed C because the reader fell behind.
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
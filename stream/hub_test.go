@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"myproject/provider"
+)
+
+func publish(h *Hub, n int) {
+	for i := 0; i < n; i++ {
+		h.Publish(provider.SMSRecord{ID: "sms-" + strconv.Itoa(i)})
+	}
+}
+
+func ids(events []Event) []uint64 {
+	out := make([]uint64, len(events))
+	for i, ev := range events {
+		out[i] = ev.ID
+	}
+	return out
+}
+
+func TestResume(t *testing.T) {
+	h := NewHub()
+	publish(h, 5)
+	all, sub := h.Subscribe(1) // Any ID below the first: whole backlog
+	sub.Close()
+	if len(all) != 5 {
+		t.Fatalf("backlog = %v, want 5 events", ids(all))
+	}
+
+	missed, sub := h.Subscribe(all[2].ID)
+	defer sub.Close()
+	if len(missed) != 2 || missed[0].ID != all[3].ID || missed[1].ID != all[4].ID {
+		t.Errorf("resume after %d = %v, want %v", all[2].ID, ids(missed), ids(all[3:]))
+	}
+
+	// The live feed carries on right after the replay: no gap, no repeat
+	h.Publish(provider.SMSRecord{ID: "live"})
+	select {
+	case ev := <-sub.C:
+		if ev.ID != all[4].ID+1 || ev.Record.ID != "live" {
+			t.Errorf("live event %d %q, want %d live", ev.ID, ev.Record.ID, all[4].ID+1)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no live event")
+	}
+
+	if missed, sub := h.Subscribe(0); len(missed) != 0 {
+		t.Errorf("no Last-Event-ID replayed %v", ids(missed))
+	} else {
+		sub.Close()
+	}
+	if missed, sub := h.Subscribe(all[4].ID + 1); len(missed) != 0 {
+		t.Errorf("up to date client replayed %v", ids(missed))
+	} else {
+		sub.Close()
+	}
+}
+
+// A client gone longer than the backlog gets what is left, oldest first
+func TestResumeOlderThanBacklog(t *testing.T) {
+	h := NewHub()
+	first := h.nextID + 1
+	publish(h, BacklogSize+10)
+
+	missed, sub := h.Subscribe(first)
+	defer sub.Close()
+	if len(missed) != BacklogSize {
+		t.Fatalf("replayed %d events, want the whole backlog (%d)", len(missed), BacklogSize)
+	}
+	if missed[0].ID != first+10 || missed[len(missed)-1].ID != first+BacklogSize+9 {
+		t.Errorf("replayed %d..%d, want %d..%d", missed[0].ID, missed[len(missed)-1].ID, first+10, first+BacklogSize+9)
+	}
+	for i := 1; i < len(missed); i++ {
+		if missed[i].ID != missed[i-1].ID+1 {
+			t.Fatalf("replay not in order at %d: %d after %d", i, missed[i].ID, missed[i-1].ID)
+		}
+	}
+}
+
+// A subscriber that doesn't read is dropped; Publish never waits for it and
+// a subscriber that keeps up still gets every event
+func TestSlowConsumer(t *testing.T) {
+	h := NewHub()
+	_, slow := h.Subscribe(0)
+	_, fast := h.Subscribe(0)
+	defer fast.Close()
+
+	done := make(chan int)
+	go func() {
+		n := 0
+		for i := 0; i < subBuffer*3; i++ {
+			h.Publish(provider.SMSRecord{ID: "sms-" + strconv.Itoa(i)})
+			if _, ok := <-fast.C; ok {
+				n++
+			}
+		}
+		done <- n
+	}()
+	select {
+	case n := <-done:
+		if n != subBuffer*3 {
+			t.Errorf("reading subscriber got %d events, want %d", n, subBuffer*3)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+	if fast.Dropped() {
+		t.Error("reading subscriber dropped")
+	}
+
+	if !slow.Dropped() {
+		t.Fatal("slow subscriber not dropped")
+	}
+	var last uint64
+	n := 0
+	for ev := range slow.C { // Closed after what fit in its buffer
+		last = ev.ID
+		n++
+	}
+	if n != subBuffer {
+		t.Errorf("slow subscriber got %d buffered events, want %d", n, subBuffer)
+	}
+	slow.Close() // Safe after the hub closed it
+
+	// Reconnecting with its last ID replays the rest
+	missed, sub := h.Subscribe(last)
+	sub.Close()
+	if len(missed) != subBuffer*2 {
+		t.Errorf("resume replayed %d events, want %d", len(missed), subBuffer*2)
+	}
+}
@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"myproject/poller"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+)
+
+const heartbeat = 15 * time.Second
+
+// Filter: ?provider=a,b&number=...&service=...
+type Filter struct {
+	Providers []string
+	poller.Filter
+}
+
+func FilterFromQuery(c *gin.Context) Filter {
+	f := Filter{Filter: poller.Filter{Number: c.Query("number"), Service: c.Query("service")}}
+	if p := c.Query("provider"); p != "" {
+		f.Providers = strings.Split(p, ",")
+	}
+	return f
+}
+
+func (f Filter) Match(rec provider.SMSRecord) bool {
+	if len(f.Providers) > 0 {
+		found := false
+		for _, p := range f.Providers {
+			if strings.EqualFold(strings.TrimSpace(p), rec.Provider) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.Filter.Match(rec)
+}
+
+// ServeSSE: GET /stream/sms. Each new SMS is sent once as
+//
+//	id: <event id>
+//	event: sms
+//	data: <SMSRecord JSON>
+//
+// Reconnects resume after Last-Event-ID (header, or ?last_event_id=).
+func (h *Hub) ServeSSE(c *gin.Context) {
+	filter := FilterFromQuery(c)
+	lastID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	if lastID == 0 {
+		lastID, _ = strconv.ParseUint(c.Query("last_event_id"), 10, 64)
+	}
+
+	missed, sub := h.Subscribe(lastID)
+	defer sub.Close()
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: don't buffer
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	w.Flush()
+
+	for _, ev := range missed {
+		if filter.Match(ev.Record) {
+			writeEvent(w, ev)
+		}
+	}
+	w.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				// Too slow: the browser reconnects with Last-Event-ID
+				return
+			}
+			if filter.Match(ev.Record) {
+				writeEvent(w, ev)
+				w.Flush()
+			}
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		}
+	}
+}
+
+func writeEvent(w gin.ResponseWriter, ev Event) {
+	data, _ := json.Marshal(ev.Record)
+	fmt.Fprintf(w, "id: %d\nevent: sms\ndata: %s\n\n", ev.ID, data)
+}
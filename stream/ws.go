@@ -0,0 +1,353 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myproject/poller"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket feed: GET /ws (?last_event_id= to resume). The client manages its
+// own subscriptions over the socket:
+//
+//	-> {"type":"subscribe","id":"a","number":"2135*","range":"algeria*","service":"whatsapp"}
+//	<- {"type":"subscribed","id":"a"}
+//	<- {"type":"sms","id":123,"subscriptions":["a"],"record":{...}}
+//	-> {"type":"unsubscribe","id":"a"}
+//	<- {"type":"ping","time":"..."}   every 15s
+//
+// Patterns are case-insensitive; "*" is a wildcard, without one number means
+// the same number and range/service mean "contains". Nothing is delivered
+// until the first subscribe.
+//
+// Browser pages on another origin are refused (403) unless listed in
+// ws_origins / WS_ORIGINS.
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsMaxSubs      = 50
+)
+
+// Pattern: One client subscription, empty fields match anything
+type Pattern struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider,omitempty"`
+	Number   string `json:"number,omitempty"`
+	Range    string `json:"range,omitempty"`
+	Service  string `json:"service,omitempty"`
+}
+
+func (p Pattern) Match(rec provider.SMSRecord) bool {
+	if p.Provider != "" && !strings.EqualFold(p.Provider, rec.Provider) {
+		return false
+	}
+	if p.Number != "" {
+		if strings.Contains(p.Number, "*") {
+			if !glob(digitsOrStar(p.Number), digitsOrStar(rec.Number)) {
+				return false
+			}
+		} else if !poller.SameNumber(p.Number, rec.Number) {
+			return false
+		}
+	}
+	if p.Range != "" && !matchText(p.Range, rec.Range) {
+		return false
+	}
+	if p.Service != "" {
+		svc := rec.Service
+		if rec.OTP != nil && rec.OTP.Service != "" {
+			svc += " " + rec.OTP.Service
+		}
+		if !matchText(p.Service, svc) && !matchText(p.Service, rec.Message) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchText: glob when the pattern has "*", substring otherwise
+func matchText(pattern, s string) bool {
+	pattern, s = strings.ToLower(pattern), strings.ToLower(s)
+	if strings.Contains(pattern, "*") {
+		return glob(pattern, s)
+	}
+	return strings.Contains(s, pattern)
+}
+
+// glob: "*" matches any run of characters, everything else is literal
+func glob(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
+
+func digitsOrStar(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if (r >= '0' && r <= '9') || r == '*' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// wsIn: Client -> server message
+type wsIn struct {
+	Type string `json:"type"`
+	Pattern
+}
+
+// wsOut: Server -> client message
+type wsOut struct {
+	Type          string              `json:"type"`
+	ID            any                 `json:"id,omitempty"`
+	Subscriptions []string            `json:"subscriptions,omitempty"`
+	Record        *provider.SMSRecord `json:"record,omitempty"`
+	Patterns      []Pattern           `json:"patterns,omitempty"`
+	Error         string              `json:"error,omitempty"`
+	LastEventID   uint64              `json:"last_event_id,omitempty"`
+	Time          *time.Time          `json:"time,omitempty"`
+}
+
+// wsClient: Per-connection subscription set
+type wsClient struct {
+	mu     sync.Mutex
+	subs   map[string]Pattern
+	order  []string // Subscription IDs in the order they were added
+	nextID int
+}
+
+func (cl *wsClient) matches(rec provider.SMSRecord) []string {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	var ids []string
+	for _, id := range cl.order {
+		if cl.subs[id].Match(rec) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (cl *wsClient) handle(msg wsIn) wsOut {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	switch msg.Type {
+	case "subscribe":
+		p := msg.Pattern
+		if p.ID == "" {
+			cl.nextID++
+			p.ID = "sub-" + strconv.Itoa(cl.nextID)
+		}
+		if _, ok := cl.subs[p.ID]; !ok {
+			if len(cl.order) >= wsMaxSubs {
+				return wsOut{Type: "error", ID: p.ID, Error: fmt.Sprintf("too many subscriptions (max %d)", wsMaxSubs)}
+			}
+			cl.order = append(cl.order, p.ID)
+		}
+		cl.subs[p.ID] = p
+		return wsOut{Type: "subscribed", ID: p.ID}
+
+	case "unsubscribe":
+		if _, ok := cl.subs[msg.ID]; !ok {
+			return wsOut{Type: "error", ID: msg.ID, Error: "unknown subscription"}
+		}
+		delete(cl.subs, msg.ID)
+		for i, id := range cl.order {
+			if id == msg.ID {
+				cl.order = append(cl.order[:i], cl.order[i+1:]...)
+				break
+			}
+		}
+		return wsOut{Type: "unsubscribed", ID: msg.ID}
+
+	case "list":
+		out := wsOut{Type: "subscriptions", Patterns: []Pattern{}}
+		for _, id := range cl.order {
+			out.Patterns = append(out.Patterns, cl.subs[id])
+		}
+		return out
+
+	case "ping":
+		return wsOut{Type: "pong"}
+	}
+	return wsOut{Type: "error", Error: "unknown message type " + strconv.Quote(msg.Type)}
+}
+
+// AllowOrigins: Web pages (e.g. "https://app.example.com") that may open
+// /ws from another origin. Call before serving.
+func (h *Hub) AllowOrigins(origins ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.origins = map[string]bool{}
+	for _, o := range origins {
+		if o = normalizeOrigin(o); o != "" {
+			h.origins[o] = true
+		}
+	}
+}
+
+// originAllowed: Browsers don't apply CORS to WebSockets, so any page could
+// read the feed; only same-origin and allowlisted pages get in. Clients
+// without an Origin header aren't browsers and are let through.
+func (h *Hub) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.origins[normalizeOrigin(origin)]
+}
+
+func normalizeOrigin(o string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(o)), "/")
+}
+
+// ServeWS: GET /ws
+func (h *Hub) ServeWS(c *gin.Context) {
+	if !h.originAllowed(c.Request) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "origin not allowed: " + c.GetHeader("Origin"), "code": "forbidden"})
+		return
+	}
+	lastID, _ := strconv.ParseUint(c.Query("last_event_id"), 10, 64)
+	// Origin was checked above; websocket.Handler's own check only parses it
+	srv := websocket.Server{Handler: func(ws *websocket.Conn) { h.serveConn(ws, lastID) }}
+	srv.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *Hub) serveConn(ws *websocket.Conn, lastID uint64) {
+	defer ws.Close()
+
+	missed, sub := h.Subscribe(lastID)
+	defer sub.Close()
+
+	cl := &wsClient{subs: map[string]Pattern{}}
+
+	// Reader: replies go through a small queue so only the loop below
+	// ever writes to the socket
+	replies := make(chan wsOut, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var msg wsIn
+			reply := wsOut{}
+			err := websocket.JSON.Receive(ws, &msg)
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			switch {
+			case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+				reply = wsOut{Type: "error", Error: "invalid message: " + err.Error()}
+			case err != nil:
+				return // Closed or broken
+			default:
+				reply = cl.handle(msg)
+			}
+			select {
+			case replies <- reply:
+			default:
+				return // Client sends faster than it reads
+			}
+		}
+	}()
+
+	send := func(m wsOut) bool {
+		ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return websocket.JSON.Send(ws, m) == nil
+	}
+	sent := lastID
+	deliver := func(ev Event) bool {
+		sent = ev.ID
+		ids := cl.matches(ev.Record)
+		if len(ids) == 0 {
+			return true
+		}
+		rec := ev.Record
+		return send(wsOut{Type: "sms", ID: ev.ID, Subscriptions: ids, Record: &rec})
+	}
+
+	pending := append([]Event{}, missed...)
+	overflow := false // Events past BacklogSize were dropped from pending
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case r := <-replies:
+			if !send(r) {
+				return
+			}
+			// Events replayed or received before the first subscribe were
+			// held back, nothing could have matched them yet
+			if r.Type == "subscribed" && pending != nil {
+				for _, ev := range pending {
+					if !deliver(ev) {
+						return
+					}
+				}
+				pending = nil
+				if overflow {
+					send(wsOut{Type: "error", Error: "too many events before the first subscribe, reconnect with last_event_id", LastEventID: sent})
+					return
+				}
+			}
+		case ev, ok := <-sub.C:
+			if !ok {
+				// Backpressure: the hub cut us off rather than stall the
+				// poller; tell the client where to resume from
+				send(wsOut{Type: "error", Error: "slow consumer, reconnect with last_event_id", LastEventID: sent})
+				return
+			}
+			if pending != nil {
+				if len(pending) < BacklogSize {
+					pending = append(pending, ev)
+				} else {
+					overflow = true
+				}
+				continue
+			}
+			if !deliver(ev) {
+				return
+			}
+		case t := <-ticker.C:
+			if !send(wsOut{Type: "ping", Time: &t}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package stream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"myproject/otp"
+	"myproject/provider"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+func TestGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"2135*", "213551234567", true},
+		{"2135*", "213661234567", false},
+		{"*4567", "213551234567", true},
+		{"213*4567", "213551234567", true},
+		{"213*4568", "213551234567", false},
+		{"2*5*7", "213551234567", true},
+		{"*", "", true},
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		{"a*a", "a", false}, // Prefix and suffix can't share the same character
+	} {
+		if got := glob(tc.pattern, tc.s); got != tc.want {
+			t.Errorf("glob(%q, %q) = %v, want %v", tc.pattern, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	rec := provider.SMSRecord{
+		Provider: "mait",
+		Number:   "213551234567",
+		Range:    "Algeria Mobilis TF04",
+		Service:  "INFO",
+		Message:  "Your WhatsApp code 482-913",
+		OTP:      &otp.Result{Code: "482913", Service: "WhatsApp"},
+	}
+	for _, tc := range []struct {
+		name string
+		p    Pattern
+		want bool
+	}{
+		{"empty", Pattern{}, true},
+		{"number prefix", Pattern{Number: "2135*"}, true},
+		{"number prefix with plus", Pattern{Number: "+213 5*"}, true},
+		{"number other prefix", Pattern{Number: "2136*"}, false},
+		{"number mid-string", Pattern{Number: "213*567"}, true},
+		{"same number", Pattern{Number: "+213551234567"}, true},
+		{"number without country code", Pattern{Number: "551234567"}, true},
+		{"too short", Pattern{Number: "4567"}, false},
+		{"range contains, case folded", Pattern{Range: "MOBILIS"}, true},
+		{"range glob", Pattern{Range: "algeria*tf04"}, true},
+		{"range glob anchored", Pattern{Range: "mobilis*"}, false},
+		{"service from OTP", Pattern{Service: "whatsapp"}, true},
+		{"service from sender", Pattern{Service: "info"}, true},
+		{"service no match", Pattern{Service: "telegram"}, false},
+		{"provider case folded", Pattern{Provider: "MAIT"}, true},
+		{"provider other", Pattern{Provider: "d-group"}, false},
+		{"all fields", Pattern{Provider: "mait", Number: "2135*", Range: "algeria*", Service: "WhatsApp"}, true},
+	} {
+		if got := tc.p.Match(rec); got != tc.want {
+			t.Errorf("%s: %+v.Match = %v, want %v", tc.name, tc.p, got, tc.want)
+		}
+	}
+}
+
+func startWS(t *testing.T, h *Hub) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws", h.ServeWS)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func dialWS(t *testing.T, base, origin string) (*websocket.Conn, error) {
+	t.Helper()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(base, "http")+"/ws", "", origin)
+	if err == nil {
+		t.Cleanup(func() { ws.Close() })
+	}
+	return ws, err
+}
+
+func receive(t *testing.T, ws *websocket.Conn) wsOut {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var m wsOut
+	if err := websocket.JSON.Receive(ws, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestWSOrigin(t *testing.T) {
+	h := NewHub()
+	h.AllowOrigins("https://app.example.com/")
+	base := startWS(t, h)
+
+	if _, err := dialWS(t, base, base); err != nil {
+		t.Errorf("same origin refused: %v", err)
+	}
+	if _, err := dialWS(t, base, "https://APP.example.com"); err != nil {
+		t.Errorf("allowlisted origin refused: %v", err)
+	}
+	if _, err := dialWS(t, base, "https://evil.example.com"); err == nil {
+		t.Error("foreign origin accepted")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/ws", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+// Events published before the first subscribe are held, then filtered by it
+func TestWSHoldsUntilSubscribe(t *testing.T) {
+	h := NewHub()
+	base := startWS(t, h)
+	ws, err := dialWS(t, base, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The ping round trip makes sure the connection subscribed to the hub
+	websocket.JSON.Send(ws, wsIn{Type: "ping"})
+	if m := receive(t, ws); m.Type != "pong" {
+		t.Fatalf("got %+v, want pong", m)
+	}
+	h.Publish(provider.SMSRecord{Provider: "mait", Number: "213551234567", Message: "first"})
+	h.Publish(provider.SMSRecord{Provider: "mait", Number: "201001234567", Message: "other"})
+	h.Publish(provider.SMSRecord{Provider: "mait", Number: "213557654321", Message: "second"})
+
+	websocket.JSON.Send(ws, wsIn{Type: "subscribe", Pattern: Pattern{ID: "dz", Number: "213*"}})
+	if m := receive(t, ws); m.Type != "subscribed" || m.ID != "dz" {
+		t.Fatalf("got %+v, want subscribed dz", m)
+	}
+	for _, want := range []string{"first", "second"} {
+		m := receive(t, ws)
+		if m.Type != "sms" || m.Record == nil || m.Record.Message != want || len(m.Subscriptions) != 1 || m.Subscriptions[0] != "dz" {
+			t.Fatalf("got %+v, want the held %q", m, want)
+		}
+	}
+
+	// Live from now on
+	h.Publish(provider.SMSRecord{Provider: "mait", Number: "213550000000", Message: "live"})
+	if m := receive(t, ws); m.Type != "sms" || m.Record.Message != "live" {
+		t.Fatalf("got %+v, want the live event", m)
+	}
+}
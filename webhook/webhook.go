@@ -0,0 +1,413 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myproject/poller"
+	"myproject/provider"
+)
+
+// Webhook delivery of new SMS. Every POST carries
+//
+//	X-Webhook-Event:     sms.new
+//	X-Webhook-Timestamp: unix seconds
+//	X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+//
+// Failed deliveries are retried with exponential backoff; after MaxAttempts
+// they land in the dead-letter list. With a Journal every delivery is kept
+// on disk until then, so a restart resends it instead of losing it.
+
+const (
+	EventNewSMS = "sms.new"
+
+	queueSize     = 1000
+	workers       = 4
+	maxDeadLetter = 500
+)
+
+// Filter: Empty fields match anything. Number matches like /otp/wait
+// (poller.SameNumber: equal, or a suffix of at least 6 digits), Service is a case-insensitive substring of sender / OTP service.
+type Filter struct {
+	Provider string `json:"provider,omitempty"`
+	Number   string `json:"number,omitempty"`
+	Country  string `json:"country,omitempty"` // ISO code, e.g. "DZ"
+	Service  string `json:"service,omitempty"`
+}
+
+type Target struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Secret  string    `json:"secret,omitempty"`
+	Filter  Filter    `json:"filter"`
+	Created time.Time `json:"created"`
+}
+
+// Payload: JSON body of every delivery
+type Payload struct {
+	Event  string             `json:"event"`
+	SentAt time.Time          `json:"sent_at"`
+	Record provider.SMSRecord `json:"record"`
+}
+
+// DeadLetter: A delivery that exhausted its retries
+type DeadLetter struct {
+	TargetID  string    `json:"target_id"`
+	URL       string    `json:"url"`
+	Payload   Payload   `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+type job struct {
+	key     string // In the Journal: record ID + " " + target URL
+	target  Target
+	payload Payload
+	attempt int
+}
+
+// Journal: Persistent store for queued deliveries (history.Store)
+type Journal interface {
+	PutPending(key string, data []byte) error
+	DeletePending(key string) error
+	Pending() (map[string][]byte, error)
+}
+
+// pending: A job as kept in the Journal. Targets are matched by URL on
+// reload; config targets get a new ID (and maybe secret) every start.
+type pending struct {
+	URL     string  `json:"url"`
+	Payload Payload `json:"payload"`
+	Attempt int     `json:"attempt"`
+}
+
+type Dispatcher struct {
+	HTTPClient  *http.Client
+	MaxAttempts int           // Default 5
+	BaseBackoff time.Duration // Default 2s, doubled per attempt
+	MaxBackoff  time.Duration // Default 5m
+
+	mu      sync.RWMutex
+	targets map[string]Target
+	dead    []DeadLetter
+	queue   chan job
+	journal Journal
+}
+
+func New() *Dispatcher {
+	d := &Dispatcher{
+		HTTPClient:  &http.Client{Timeout: 15 * time.Second},
+		MaxAttempts: 5,
+		BaseBackoff: 2 * time.Second,
+		MaxBackoff:  5 * time.Minute,
+		targets:     map[string]Target{},
+		queue:       make(chan job, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// ---------------------------------------------------------
+// TARGET REGISTRY
+// ---------------------------------------------------------
+
+// Add registers a target. A random secret is generated when none is given.
+func (d *Dispatcher) Add(t Target) (Target, error) {
+	u, err := url.Parse(t.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Target{}, errors.New("webhook: url must be http(s)://host/...")
+	}
+	if t.ID == "" {
+		t.ID = randomHex(8)
+	}
+	if t.Secret == "" {
+		t.Secret = randomHex(16)
+	}
+	t.Created = time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.targets[t.ID]; exists {
+		return Target{}, fmt.Errorf("webhook: id %q already registered", t.ID)
+	}
+	d.targets[t.ID] = t
+	fmt.Printf("[Webhook] Registered %s -> %s\n", t.ID, t.URL)
+	return t, nil
+}
+
+func (d *Dispatcher) Remove(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.targets[id]
+	delete(d.targets, id)
+	return ok
+}
+
+// Targets lists registrations with secrets hidden.
+func (d *Dispatcher) Targets() []Target {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := []Target{}
+	for _, t := range d.targets {
+		t.Secret = ""
+		out = append(out, t)
+	}
+	return out
+}
+
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]DeadLetter{}, d.dead...)
+}
+
+// ClearDeadLetters empties the list and returns how many were dropped.
+func (d *Dispatcher) ClearDeadLetters() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := len(d.dead)
+	d.dead = nil
+	return n
+}
+
+// UseJournal: Deliveries are journaled from now on, and the ones a previous
+// run left pending are queued again. Call after the config targets are
+// registered; pending deliveries for a URL no target uses any more are
+// dropped.
+func (d *Dispatcher) UseJournal(journal Journal) error {
+	saved, err := journal.Pending()
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.journal = journal
+	byURL := map[string]Target{}
+	for _, t := range d.targets {
+		byURL[t.URL] = t
+	}
+	d.mu.Unlock()
+
+	resent := 0
+	for key, data := range saved {
+		var p pending
+		json.Unmarshal(data, &p)
+		t, ok := byURL[p.URL]
+		if !ok {
+			journal.DeletePending(key)
+			continue
+		}
+		d.enqueue(job{key: key, target: t, payload: p.Payload, attempt: max(p.Attempt, 1)})
+		resent++
+	}
+	if len(saved) > 0 {
+		fmt.Printf("[Webhook] %d pending deliveries resent, %d dropped\n", resent, len(saved)-resent)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------
+// DELIVERY
+// ---------------------------------------------------------
+
+// Notify queues rec for every matching target. Never blocks; when the
+// queue is full the delivery is retried later like a failed one.
+func (d *Dispatcher) Notify(rec provider.SMSRecord) {
+	payload := Payload{Event: EventNewSMS, Record: rec}
+
+	d.mu.RLock()
+	var matched []Target
+	for _, t := range d.targets {
+		if t.Filter.Match(rec) {
+			matched = append(matched, t)
+		}
+	}
+	d.mu.RUnlock()
+
+	id := rec.ID
+	if id == "" {
+		id = provider.Fingerprint(rec)
+	}
+	for _, t := range matched {
+		j := job{key: id + " " + t.URL, target: t, payload: payload, attempt: 1}
+		d.save(j)
+		d.enqueue(j)
+	}
+}
+
+func (d *Dispatcher) enqueue(j job) {
+	select {
+	case d.queue <- j:
+	default:
+		d.retry(j, errors.New("queue full"))
+	}
+}
+
+func (d *Dispatcher) worker() {
+	for j := range d.queue {
+		if err := d.send(j.target, j.payload); err != nil {
+			d.retry(j, err)
+			continue
+		}
+		d.forget(j)
+	}
+}
+
+// save: Journals j (with its current attempt); no-op without a Journal
+func (d *Dispatcher) save(j job) {
+	d.mu.RLock()
+	journal := d.journal
+	d.mu.RUnlock()
+	if journal == nil {
+		return
+	}
+	data, _ := json.Marshal(pending{URL: j.target.URL, Payload: j.payload, Attempt: j.attempt})
+	if err := journal.PutPending(j.key, data); err != nil {
+		fmt.Printf("[Webhook] journal: %v\n", err)
+	}
+}
+
+// forget: j is acknowledged or buried, drop it from the Journal
+func (d *Dispatcher) forget(j job) {
+	d.mu.RLock()
+	journal := d.journal
+	d.mu.RUnlock()
+	if journal == nil {
+		return
+	}
+	if err := journal.DeletePending(j.key); err != nil {
+		fmt.Printf("[Webhook] journal: %v\n", err)
+	}
+}
+
+// retry: Queues j again after its backoff, or buries it once MaxAttempts
+// is used up. Not getting into a full queue costs an attempt too, so a
+// burst delays deliveries instead of dead-lettering them.
+func (d *Dispatcher) retry(j job, err error) {
+	if j.attempt >= d.MaxAttempts {
+		d.bury(j, err)
+		return
+	}
+	wait := d.backoff(j.attempt)
+	fmt.Printf("[Webhook] %s attempt %d failed (%v), retry in %s\n", j.target.ID, j.attempt, err, wait)
+	j.attempt++
+	d.save(j)
+	time.AfterFunc(wait, func() { d.enqueue(j) })
+}
+
+// backoff: BaseBackoff * 2^(attempt-1), capped at MaxBackoff
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempt && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+	return wait
+}
+
+func (d *Dispatcher) bury(j job, err error) {
+	fmt.Printf("[Webhook] %s gave up after %d attempts: %v\n", j.target.ID, j.attempt, err)
+	d.forget(j)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dead = append(d.dead, DeadLetter{
+		TargetID:  j.target.ID,
+		URL:       j.target.URL,
+		Payload:   j.payload,
+		Attempts:  j.attempt,
+		LastError: err.Error(),
+		FailedAt:  time.Now(),
+	})
+	if len(d.dead) > maxDeadLetter {
+		d.dead = d.dead[len(d.dead)-maxDeadLetter:]
+	}
+}
+
+// send: One POST; any non-2xx status is a failure
+func (d *Dispatcher) send(t Target, payload Payload) error {
+	payload.SentAt = time.Now().UTC()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(payload.SentAt.Unix(), 10)
+
+	req, err := http.NewRequest("POST", t.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "otp-apis-webhook/1")
+	req.Header.Set("X-Webhook-Id", t.ID)
+	req.Header.Set("X-Webhook-Event", payload.Event)
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(t.Secret, ts, body))
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign is the signature receivers should recompute and compare.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ---------------------- MATCHING ----------------------
+
+func (f Filter) Match(rec provider.SMSRecord) bool {
+	if f.Provider != "" && !strings.EqualFold(f.Provider, rec.Provider) {
+		return false
+	}
+	if f.Country != "" && !strings.EqualFold(f.Country, rec.Country) {
+		return false
+	}
+	if f.Number != "" && !poller.SameNumber(f.Number, rec.Number) {
+		return false
+	}
+	if f.Service != "" {
+		hay := strings.ToLower(rec.Service)
+		if rec.OTP != nil {
+			hay += " " + strings.ToLower(rec.OTP.Service)
+		}
+		if !strings.Contains(hay, strings.ToLower(f.Service)) {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
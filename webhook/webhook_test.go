@@ -0,0 +1,331 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"myproject/provider"
+)
+
+func TestFilterNumber(t *testing.T) {
+	rec := provider.SMSRecord{Number: "+213551234561", Provider: "mait", Country: "DZ"}
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"", true},
+		{"213551234561", true},
+		{"+213 551 234561", true},
+		{"551234561", true}, // Without country code
+		{"1", false},        // Too short to identify a number
+		{"34561", false},
+		{"213551234562", false},
+	}
+	for _, tt := range tests {
+		if got := (Filter{Number: tt.number}).Match(rec); got != tt.want {
+			t.Errorf("Filter{Number: %q}.Match = %v, want %v", tt.number, got, tt.want)
+		}
+	}
+}
+
+// dispatcher: No workers, a tiny queue, millisecond backoff
+func dispatcher(queue int) *Dispatcher {
+	return &Dispatcher{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		targets:     map[string]Target{},
+		queue:       make(chan job, queue),
+	}
+}
+
+func TestFullQueueRetries(t *testing.T) {
+	d := dispatcher(1)
+	d.enqueue(job{target: Target{ID: "a"}, attempt: 1})
+	d.enqueue(job{target: Target{ID: "b"}, attempt: 1}) // Queue full
+
+	if n := len(d.DeadLetters()); n != 0 {
+		t.Fatalf("%d dead letters right after a full queue, want a retry", n)
+	}
+	if j := <-d.queue; j.target.ID != "a" {
+		t.Fatalf("first job = %s", j.target.ID)
+	}
+	select {
+	case j := <-d.queue:
+		if j.target.ID != "b" || j.attempt != 2 {
+			t.Errorf("retried job = %s attempt %d, want b attempt 2", j.target.ID, j.attempt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("job b was never re-queued")
+	}
+}
+
+func TestFullQueueBuriedAfterLastAttempt(t *testing.T) {
+	d := dispatcher(1)
+	d.queue <- job{target: Target{ID: "stuck"}, attempt: 1}
+	d.enqueue(job{target: Target{ID: "b"}, attempt: 1})
+
+	deadline := time.Now().Add(time.Second)
+	for len(d.DeadLetters()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	dead := d.DeadLetters()
+	if len(dead) != 1 || dead[0].TargetID != "b" || dead[0].Attempts != d.MaxAttempts {
+		t.Fatalf("dead letters = %+v, want b after %d attempts", dead, d.MaxAttempts)
+	}
+}
+
+// memJournal: Journal in a map
+type memJournal struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (m *memJournal) PutPending(key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = data
+	return nil
+}
+
+func (m *memJournal) DeletePending(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memJournal) Pending() (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := map[string][]byte{}
+	for k, v := range m.data {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (m *memJournal) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.data)
+}
+
+// A delivery stays journaled until it is acknowledged
+func TestJournalClearedOn2xx(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+
+	d := dispatcher(10)
+	d.HTTPClient = srv.Client()
+	journal := &memJournal{data: map[string][]byte{}}
+	d.UseJournal(journal)
+	d.Add(Target{URL: srv.URL})
+	d.Notify(provider.SMSRecord{ID: "sms-1"})
+
+	if n := journal.len(); n != 1 {
+		t.Fatalf("%d journaled deliveries while in flight, want 1", n)
+	}
+	go d.worker()
+	close(release)
+	waitFor(t, func() bool { return journal.len() == 0 })
+}
+
+// Pending deliveries from a previous run go out again to the target with
+// the same URL; ones for a URL nobody uses any more are dropped
+func TestJournalResend(t *testing.T) {
+	got := make(chan Payload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Payload
+		json.NewDecoder(r.Body).Decode(&p)
+		got <- p
+	}))
+	defer srv.Close()
+
+	journal := &memJournal{data: map[string][]byte{}}
+	for key, url := range map[string]string{"sms-1 " + srv.URL: srv.URL, "sms-2 http://gone.invalid/": "http://gone.invalid/"} {
+		data, _ := json.Marshal(pending{URL: url, Payload: Payload{Event: EventNewSMS, Record: provider.SMSRecord{ID: key[:5]}}, Attempt: 2})
+		journal.data[key] = data
+	}
+
+	d := dispatcher(10)
+	d.HTTPClient = srv.Client()
+	d.Add(Target{URL: srv.URL}) // A new ID, as after a restart
+	if err := d.UseJournal(journal); err != nil {
+		t.Fatal(err)
+	}
+	go d.worker()
+
+	select {
+	case p := <-got:
+		if p.Record.ID != "sms-1" {
+			t.Errorf("resent record %q, want sms-1", p.Record.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending delivery was not resent")
+	}
+	waitFor(t, func() bool { return journal.len() == 0 })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// receiver: httptest server answering each POST with the next status in
+// codes (200 once they run out), recording the requests
+type receiver struct {
+	*httptest.Server
+	mu    sync.Mutex
+	codes []int
+	reqs  []received
+}
+
+type received struct {
+	header http.Header
+	body   []byte
+	at     time.Time
+}
+
+func newReceiver(t *testing.T, codes ...int) *receiver {
+	rcv := &receiver{codes: codes}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		defer rcv.mu.Unlock()
+		rcv.reqs = append(rcv.reqs, received{header: r.Header, body: body, at: time.Now()})
+		code := http.StatusOK
+		if len(rcv.codes) > 0 {
+			code, rcv.codes = rcv.codes[0], rcv.codes[1:]
+		}
+		w.WriteHeader(code)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func (rcv *receiver) requests() []received {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return append([]received{}, rcv.reqs...)
+}
+
+// started: dispatcher with one worker posting to rcv
+func started(rcv *receiver) *Dispatcher {
+	d := dispatcher(10)
+	d.HTTPClient = rcv.Client()
+	go d.worker()
+	return d
+}
+
+func TestSignature(t *testing.T) {
+	rcv := newReceiver(t)
+	d := started(rcv)
+	target, err := d.Add(Target{URL: rcv.URL, Secret: "shared-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Notify(provider.SMSRecord{ID: "sms-1", Provider: "mait", Message: "code 1234"})
+	waitFor(t, func() bool { return len(rcv.requests()) == 1 })
+
+	req := rcv.requests()[0]
+	ts := req.header.Get("X-Webhook-Timestamp")
+	if want := "sha256=" + Sign("shared-secret", ts, req.body); req.header.Get("X-Webhook-Signature") != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", req.header.Get("X-Webhook-Signature"), want)
+	}
+	if bad := "sha256=" + Sign("other-secret", ts, req.body); req.header.Get("X-Webhook-Signature") == bad {
+		t.Error("signature verifies with the wrong secret")
+	}
+	if got := req.header.Get("X-Webhook-Id"); got != target.ID {
+		t.Errorf("X-Webhook-Id = %q, want %q", got, target.ID)
+	}
+	if got := req.header.Get("X-Webhook-Event"); got != EventNewSMS {
+		t.Errorf("X-Webhook-Event = %q", got)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(req.body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Record.ID != "sms-1" || p.Record.Message != "code 1234" {
+		t.Errorf("payload record = %+v", p.Record)
+	}
+	if sent, _ := strconv.ParseInt(ts, 10, 64); sent != p.SentAt.Unix() {
+		t.Errorf("timestamp %s != sent_at %s", ts, p.SentAt)
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	rcv := newReceiver(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+	d := started(rcv)
+	d.MaxAttempts = 5
+	d.BaseBackoff = 20 * time.Millisecond
+	d.MaxBackoff = time.Second
+	d.Add(Target{URL: rcv.URL})
+	d.Notify(provider.SMSRecord{ID: "sms-1"})
+
+	waitFor(t, func() bool { return len(rcv.requests()) == 3 })
+	reqs := rcv.requests()
+	// 20ms after the first failure, 40ms after the second
+	if gap := reqs[1].at.Sub(reqs[0].at); gap < 20*time.Millisecond {
+		t.Errorf("first retry after %s, want >= 20ms", gap)
+	}
+	if gap := reqs[2].at.Sub(reqs[1].at); gap < 40*time.Millisecond {
+		t.Errorf("second retry after %s, want >= 40ms", gap)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(rcv.requests()); n != 3 {
+		t.Errorf("%d requests, want none after the 200", n)
+	}
+	if dead := d.DeadLetters(); len(dead) != 0 {
+		t.Errorf("dead letters = %+v, want none", dead)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{BaseBackoff: 2 * time.Second, MaxBackoff: 10 * time.Second}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
+
+func TestDeadLetterAfterLastAttempt(t *testing.T) {
+	rcv := newReceiver(t, 500, 502, 503, 200)
+	d := started(rcv) // MaxAttempts 3
+	target, _ := d.Add(Target{URL: rcv.URL})
+	d.Notify(provider.SMSRecord{ID: "sms-1"})
+
+	waitFor(t, func() bool { return len(d.DeadLetters()) == 1 })
+	dead := d.DeadLetters()[0]
+	if dead.TargetID != target.ID || dead.URL != rcv.URL || dead.Attempts != 3 {
+		t.Errorf("dead letter = %+v, want %s after 3 attempts", dead, target.ID)
+	}
+	if dead.LastError != "HTTP 503" {
+		t.Errorf("LastError = %q, want HTTP 503", dead.LastError)
+	}
+	if dead.Payload.Record.ID != "sms-1" {
+		t.Errorf("dead letter record = %q", dead.Payload.Record.ID)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(rcv.requests()); n != 3 {
+		t.Errorf("%d requests, want exactly MaxAttempts (3)", n)
+	}
+}